@@ -0,0 +1,107 @@
+package main
+
+import (
+	"GoShort/internal/db"
+	"GoShort/internal/models"
+	"GoShort/internal/store"
+	"GoShort/pkg/config"
+	"GoShort/pkg/logger"
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+// newLinkStore puts the configured caches in front of links: an in-process cache and, when
+// Redis is configured, a cache shared by every replica. Invalidations published by the
+// replicas are applied to the in-process cache until ctx is done. The returned function
+// closes the Redis connection.
+func newLinkStore(ctx context.Context, cfg config.CacheConfig, links store.LinkStore) (store.LinkStore, func(), error) {
+	if !cfg.Enabled {
+		return links, func() {}, nil
+	}
+	opts := cacheOptions(cfg)
+
+	client, err := newRedisClient(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	if client == nil {
+		return store.NewCachedStore(links, opts), func() {}, nil
+	}
+
+	shared := store.NewRedisStore(links, client, cfg.Redis.KeyPrefix, opts)
+	cache := store.NewCachedStore(shared, opts)
+	go shared.Listen(ctx, cache.Evict, cache.EvictDomain, cache.EvictAll)
+	return cache, func() { client.Close() }, nil
+}
+
+// newRedisClient connects to the Redis server shared by the replicas, or returns nil when
+// the shared cache is not configured
+func newRedisClient(cfg config.CacheConfig) (*redis.Client, error) {
+	if !cfg.Enabled || cfg.Redis.URL == "" {
+		return nil, nil
+	}
+	opts, err := redis.ParseURL(cfg.Redis.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Redis URL: %w", err)
+	}
+	return redis.NewClient(opts), nil
+}
+
+// cacheOptions converts the cache configuration
+func cacheOptions(cfg config.CacheConfig) store.CacheOptions {
+	return store.CacheOptions{
+		Size:        cfg.Size,
+		TTL:         time.Duration(cfg.TTL),
+		NegativeTTL: time.Duration(cfg.NegativeTTL),
+	}
+}
+
+const (
+	// maxWarmLinks is the most links loaded into the cache on startup
+	maxWarmLinks = 1000
+	// cacheWarmTimeout bounds how long startup waits for the cache to warm up
+	cacheWarmTimeout = 30 * time.Second
+)
+
+// cacheWarmer loads the most visited links into the in-process cache on startup, so that a
+// new replica does not send its first redirects to the database, and reports readiness once
+// it is done
+type cacheWarmer struct {
+	done atomic.Bool
+}
+
+// warm loads up to limit of the most visited links into cache. Failures are logged, since a
+// cold cache still serves redirects.
+func (w *cacheWarmer) warm(ctx context.Context, cache *store.CachedStore, limit int) {
+	defer w.done.Store(true)
+
+	ctx, cancel := context.WithTimeout(ctx, cacheWarmTimeout)
+	defer cancel()
+
+	start := time.Now()
+	version := cache.Version()
+	var urls []*models.URL
+	err := db.Read(ctx, func(conn *gorm.DB) error {
+		return conn.Preload("Variants").Preload("Schedules").Order("clicks DESC").Limit(limit).Find(&urls).Error
+	})
+	if err != nil {
+		logger.Error("Failed to warm the link cache", logger.Fields{"error": err})
+		return
+	}
+	cache.Warm(urls, version)
+	logger.Info("Link cache warmed", logger.Fields{"links": len(urls), "latency_ms": float64(time.Since(start).Microseconds()) / 1000})
+}
+
+// Check fails until the cache has been warmed
+func (w *cacheWarmer) Check(context.Context) error {
+	if !w.done.Load() {
+		return errors.New("link cache is warming up")
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"GoShort/internal/db"
+	"GoShort/internal/models"
+	"GoShort/internal/store"
+	"GoShort/migrations"
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestCacheWarmer(t *testing.T) {
+	conn, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "goshort.db")), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+	_, err = migrations.Up(conn)
+	require.NoError(t, err)
+	previous := db.DB
+	db.DB = conn
+	t.Cleanup(func() { db.DB = previous })
+
+	require.NoError(t, conn.Create(&[]models.URL{
+		{ShortURL: "popular", LongURL: "https://example.com/popular", Clicks: 10},
+		{ShortURL: "quiet", LongURL: "https://example.com/quiet", Clicks: 1},
+	}).Error)
+
+	cache := store.NewCachedStore(store.NewSQLStore(conn), store.CacheOptions{Size: 10, TTL: time.Minute})
+	warmer := &cacheWarmer{}
+	assert.Error(t, warmer.Check(context.Background()), "Not ready until warmed")
+
+	// Only the most visited links are loaded
+	warmer.warm(context.Background(), cache, 1)
+	assert.NoError(t, warmer.Check(context.Background()))
+	assert.Equal(t, 1, cache.Stats().Entries)
+	_, err = cache.FindLink(context.Background(), "", "popular")
+	assert.NoError(t, err)
+	assert.Equal(t, store.CacheStats{Hits: 1, Entries: 1}, cache.Stats())
+}
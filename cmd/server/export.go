@@ -0,0 +1,75 @@
+package main
+
+import (
+	"GoShort/internal/db"
+	"GoShort/internal/exporter"
+	"GoShort/pkg/logger"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// runExport implements the `goshort export` command
+func runExport(args []string) {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	kind := fs.String("type", "links", "data to export: links or clicks")
+	format := fs.String("format", exporter.FormatNDJSON, "output format: csv, json or ndjson")
+	since := fs.String("since", "", "only export records created at or after this RFC3339 time")
+	until := fs.String("until", "", "only export records created before this RFC3339 time")
+	output := fs.String("o", "-", "output file, - for stdout")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: goshort export [options]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if !exporter.ValidFormat(*format) {
+		logger.Fatal("Unsupported format", logger.Fields{"format": *format})
+	}
+
+	var filter exporter.Filter
+	bounds := []struct {
+		value string
+		field *time.Time
+	}{{*since, &filter.Since}, {*until, &filter.Until}}
+	for _, bound := range bounds {
+		if bound.value == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339, bound.value)
+		if err != nil {
+			logger.Fatal("Invalid time", logger.Fields{"time": bound.value, "error": err})
+		}
+		*bound.field = parsed
+	}
+
+	var export func(*gorm.DB, io.Writer, string, exporter.Filter) error
+	switch *kind {
+	case "links":
+		export = exporter.ExportLinks
+	case "clicks":
+		export = exporter.ExportClicks
+	default:
+		logger.Fatal("Unsupported export type", logger.Fields{"type": *kind})
+	}
+
+	var out io.Writer = os.Stdout
+	if *output != "-" {
+		file, err := os.Create(*output)
+		if err != nil {
+			logger.Fatal("Failed to create output file", logger.Fields{"error": err})
+		}
+		defer file.Close()
+		out = file
+	}
+
+	mustInitDB(mustLoadConfig())
+
+	if err := export(db.DB, out, *format, filter); err != nil {
+		logger.Fatal("Export failed", logger.Fields{"error": err})
+	}
+}
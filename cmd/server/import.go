@@ -0,0 +1,108 @@
+package main
+
+import (
+	"GoShort/internal/db"
+	"GoShort/internal/importer"
+	"GoShort/internal/models"
+	"GoShort/internal/store"
+	"GoShort/pkg/config"
+	"GoShort/pkg/logger"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// runImport implements the `goshort import` command
+func runImport(args []string) {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	format := fs.String("format", "", "export format: "+strings.Join(importer.Formats, ", "))
+	strategy := fs.String("strategy", string(importer.StrategySkip), "conflict strategy: skip, overwrite or rename")
+	dryRun := fs.Bool("dry-run", false, "report what would be imported without writing anything")
+	asJSON := fs.Bool("json", false, "print the full report as JSON")
+	domain := fs.String("domain", "", "custom domain to import the links into")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: goshort import -format <format> [options] <file|->")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 1 || *format == "" {
+		fs.Usage()
+		os.Exit(2)
+	}
+	conflicts, err := importer.ParseStrategy(*strategy)
+	if err != nil {
+		logger.Fatal("Invalid conflict strategy", logger.Fields{"error": err})
+	}
+
+	var input io.Reader = os.Stdin
+	if path := fs.Arg(0); path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			logger.Fatal("Failed to open export", logger.Fields{"error": err})
+		}
+		defer file.Close()
+		input = file
+	}
+
+	records, err := importer.Parse(*format, input)
+	if err != nil {
+		logger.Fatal("Failed to parse export", logger.Fields{"error": err})
+	}
+
+	cfg := mustLoadConfig()
+	mustInitDB(cfg)
+
+	host := strings.ToLower(*domain)
+	if host != "" {
+		if err := db.DB.Where("host = ?", host).First(&models.Domain{}).Error; err != nil {
+			logger.Fatal("Unknown domain", logger.Fields{"domain": *domain})
+		}
+	}
+
+	report, err := importer.Import(db.DB, records, importer.Options{Strategy: conflicts, DryRun: *dryRun, Domain: host})
+	if err != nil {
+		logger.Fatal("Import failed", logger.Fields{"error": err})
+	}
+	invalidateImported(cfg.Cache, host, report)
+
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		encoder.Encode(report)
+		return
+	}
+
+	for _, item := range report.Items {
+		switch item.Action {
+		case importer.ActionFail:
+			fmt.Printf("line %d: %s: %s\n", item.Line, item.ShortURL, item.Error)
+		case importer.ActionRename:
+			fmt.Printf("line %d: %s renamed to %s\n", item.Line, item.ShortURL, item.NewShortURL)
+		}
+	}
+	if report.DryRun {
+		fmt.Println("Dry run, nothing was written.")
+	}
+	fmt.Printf("Total: %d, created: %d, overwritten: %d, renamed: %d, skipped: %d, failed: %d\n",
+		report.Total, report.Created, report.Overwritten, report.Renamed, report.Skipped, report.Failed)
+}
+
+// invalidateImported makes running replicas drop their cached copies of the links written.
+// Without a shared Redis cache they keep serving them until the cache TTL passes.
+func invalidateImported(cfg config.CacheConfig, domain string, report *importer.Report) {
+	client, err := newRedisClient(cfg)
+	if err != nil || client == nil {
+		return
+	}
+	defer client.Close()
+
+	shared := store.NewRedisStore(nil, client, cfg.Redis.KeyPrefix, cacheOptions(cfg))
+	for _, shortURL := range report.Written() {
+		shared.Invalidate(context.Background(), domain, shortURL)
+	}
+}
@@ -1,32 +1,237 @@
 package main
 
 import (
+	"GoShort/internal/analytics"
+	v1 "GoShort/internal/api/v1"
+	"GoShort/internal/db"
+	"GoShort/internal/metadata"
+	"GoShort/internal/metrics"
+	"GoShort/internal/models"
+	"GoShort/internal/store"
+	"GoShort/internal/tracing"
+	"GoShort/internal/utils"
+	"GoShort/migrations"
 	"GoShort/pkg/config"
 	"GoShort/pkg/logger"
-	"GoShort/internal/db"
-	"log"
-	"net/http"
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 )
 
 var version = "dev"
 
+// configWatchInterval is how often the config file is checked for changes
+const configWatchInterval = 5 * time.Second
+
 func main() {
+	// Run a maintenance command instead of the server when one is given
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "import":
+			runImport(os.Args[2:])
+		case "export":
+			runExport(os.Args[2:])
+		case "migrate":
+			runMigrate(os.Args[2:])
+		default:
+			fmt.Fprintf(os.Stderr, "Unknown command %q\nUsage: goshort [import|export|migrate]\n", os.Args[1])
+			os.Exit(2)
+		}
+		return
+	}
+
 	// Load configuration
-	config.Load()
+	cfg := mustLoadConfig()
+
+	// Stop gracefully on SIGTERM (sent by supervisord and docker stop) or Ctrl+C
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
+	defer stop()
+
+	// Export traces of requests, queries, cache lookups and scans
+	shutdownTracing, err := tracing.Init(ctx, cfg.Tracing, version)
+	if err != nil {
+		logger.Fatal("Failed to set up tracing", logger.Fields{"error": err})
+	}
+
+	if err := v1.LoadBrandingFile(cfg.Branding.File); err != nil {
+		logger.Fatal("Failed to load the branding file", logger.Fields{"path": cfg.Branding.File, "error": err})
+	}
 
-	// Initialize logger
-	logger.Init()
+	// Reload branding, domain policy, rate limits and logging on SIGHUP or config and branding
+	// file changes
+	go config.Watch(ctx, configWatchInterval, func(cfg *config.Config) {
+		if err := configureLogger(cfg.Log); err != nil {
+			logger.Error("Failed to change logging settings", logger.Fields{"error": err})
+		}
+		if err := v1.LoadBrandingFile(cfg.Branding.File); err != nil {
+			logger.Error("Failed to reload the branding file, keeping the current one", logger.Fields{"path": cfg.Branding.File, "error": err})
+		}
+	})
 
 	// Initialize database
-	db.InitDB()
+	mustInitDB(cfg)
+	go db.WatchReplicas(ctx, time.Duration(cfg.Database.ReplicaCheck))
+
+	// Cache link lookups in memory, and in Redis when replicas share one
+	links, closeCache, err := newLinkStore(ctx, cfg.Cache, store.NewReplicatedSQLStore(db.DB, db.Reader, db.ReplicaFailed))
+	if err != nil {
+		logger.Fatal("Failed to set up the link cache", logger.Fields{"error": err})
+	}
+	v1.Links = links
+
+	// Report readiness once the database is reachable and migrated
+	v1.ReadinessChecks["database"] = v1.ReadinessCheck{Check: db.Ping}
+	v1.ReadinessChecks["migrations"] = v1.ReadinessCheck{Check: migrationsApplied}
+	if cache, ok := links.(store.Pinger); ok && cfg.Cache.Redis.URL != "" {
+		// Redirects fall back to the database while the shared cache is down
+		v1.ReadinessChecks["cache"] = v1.ReadinessCheck{Check: cache.Ping, Optional: true}
+	}
+	if cache, ok := links.(*store.CachedStore); ok {
+		warmer := &cacheWarmer{}
+		go warmer.warm(ctx, cache, min(cfg.Cache.Size, maxWarmLinks))
+		v1.ReadinessChecks["cache_warm"] = v1.ReadinessCheck{Check: warmer.Check}
+	}
+	go warnReservedShortURLs(ctx)
+
+	// Export the connection pool, cache and click queue to Prometheus
+	if cfg.Metrics.Enabled {
+		if cfg.Metrics.Token == "" {
+			logger.Warn("Metrics are served without a token, set metrics.token unless /metrics is not exposed", nil)
+		}
+		registerMetrics(links)
+	}
+
+	// Start the destination metadata fetcher
+	if cfg.Features.MetadataFetch {
+		cache, _ := links.(store.Invalidator)
+		v1.MetadataFetcher = metadata.NewFetcher(4, cache)
+	}
+
+	// Count clicks in the background, storing click events when analytics are on
+	v1.ClickRecorder = analytics.NewRecorder(db.DB, cfg.Features.Analytics)
+	if cfg.Metrics.Enabled {
+		metrics.RegisterClickRecorder(v1.ClickRecorder)
+	}
 
 	// Set up HTTP server
 	router := setupRouter()
 
 	// Start the server
-	log.Println("Starting GoShort server on port 8080...")
-	log.Printf("Version: %s\n", version)
-	if err := http.ListenAndServe(":8080", router); err != nil {
-		log.Fatalf("Server failed to start: %v", err)
+	logger.Info("Starting GoShort server", logger.Fields{"addr": cfg.Server.Addr, "version": version})
+	server := newServer(cfg.Server, router)
+	listeners := []listener{{server, server.ListenAndServe}}
+	if cfg.TLS.Enabled {
+		if listeners, err = tlsListeners(cfg, router); err != nil {
+			logger.Fatal("Failed to set up TLS", logger.Fields{"error": err})
+		}
+		logger.Info("Serving HTTPS", logger.Fields{"addr": cfg.TLS.Addr})
+	}
+	deadline, err := serve(ctx, time.Duration(cfg.Server.ShutdownTimeout), listeners...)
+	if err != nil {
+		logger.Error("Server failed", logger.Fields{"error": err})
+	}
+
+	// Flush pending work once no more requests can come in, within the same shutdown timeout
+	// so that supervisord does not kill the process halfway
+	shutdownCtx, cancel := context.WithDeadline(context.Background(), deadline)
+	logger.Info("Flushing clicks", nil)
+	if err := v1.ClickRecorder.Close(shutdownCtx); err != nil {
+		logger.Error("Failed to flush clicks", logger.Fields{"error": err})
+	}
+	if v1.MetadataFetcher != nil {
+		if err := v1.MetadataFetcher.Close(shutdownCtx); err != nil {
+			logger.Warn("Metadata fetches did not stop in time", logger.Fields{"error": err})
+		}
+	}
+	closeCache()
+	if err := db.Close(); err != nil {
+		logger.Error("Failed to close the database", logger.Fields{"error": err})
+	}
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		logger.Error("Failed to flush traces", logger.Fields{"error": err})
+	}
+	cancel()
+	logger.Info("Server stopped", nil)
+	if err != nil {
+		os.Exit(1)
+	}
+}
+
+// mustLoadConfig loads and validates the configuration, exiting with every problem found,
+// and sets up logging from it
+func mustLoadConfig() *config.Config {
+	cfg, err := config.Load()
+	if err != nil {
+		logger.Fatal("Invalid configuration", logger.Fields{"error": err})
+	}
+	if err := configureLogger(cfg.Log); err != nil {
+		logger.Fatal("Invalid logging settings", logger.Fields{"error": err})
+	}
+	return cfg
+}
+
+// configureLogger applies the log level and format
+func configureLogger(cfg config.LogConfig) error {
+	if err := logger.SetLevel(cfg.Level); err != nil {
+		return err
+	}
+	return logger.SetFormat(cfg.Format)
+}
+
+// migrationsApplied fails while migrations are pending, e.g. until `goshort migrate up` is run
+func migrationsApplied(ctx context.Context) error {
+	pending, err := migrations.Pending(db.DB.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("%d pending migration(s)", len(pending))
+	}
+	return nil
+}
+
+// warnReservedShortURLs lists the links created before their short URL was reserved, which
+// cannot be reached since GoShort serves those paths itself
+func warnReservedShortURLs(ctx context.Context) {
+	var urls []models.URL
+	err := db.DB.WithContext(ctx).Select("domain", "short_url").Where("LOWER(short_url) IN ?", utils.ReservedShortURLs).Find(&urls).Error
+	if err != nil {
+		logger.Error("Failed to look for reserved short URLs", logger.Fields{"error": err})
+		return
+	}
+	if len(urls) == 0 {
+		return
+	}
+	names := make([]string, len(urls))
+	for i, url := range urls {
+		names[i] = url.Domain + "/" + url.ShortURL
+	}
+	logger.Warn("Short URLs shadowed by reserved paths cannot be reached, rename them", logger.Fields{"short_urls": names, "reserved": utils.ReservedShortURLs})
+}
+
+// mustInitDB connects to the database and applies pending migrations unless they are run separately
+func mustInitDB(cfg *config.Config) {
+	db.InitDB()
+	if !cfg.Database.AutoMigrate {
+		return
+	}
+	if _, err := migrations.Up(db.DB); err != nil {
+		logger.Fatal("Failed to migrate the database", logger.Fields{"error": err})
+	}
+}
+
+// registerMetrics exports the database pools and the link cache to Prometheus
+func registerMetrics(links store.LinkStore) {
+	if sqlDB, err := db.DB.DB(); err == nil {
+		metrics.RegisterDB(sqlDB, "primary")
+	}
+	for i, sqlDB := range db.ReplicaPools() {
+		metrics.RegisterDB(sqlDB, fmt.Sprintf("replica-%d", i))
+	}
+	if cache, ok := links.(interface{ Stats() store.CacheStats }); ok {
+		metrics.RegisterCache(cache.Stats)
 	}
 }
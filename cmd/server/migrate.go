@@ -0,0 +1,62 @@
+package main
+
+import (
+	"GoShort/internal/db"
+	"GoShort/migrations"
+	"GoShort/pkg/logger"
+	"flag"
+	"fmt"
+	"os"
+)
+
+// runMigrate implements the `goshort migrate` command
+func runMigrate(args []string) {
+	fs := flag.NewFlagSet("migrate", flag.ExitOnError)
+	steps := fs.Int("steps", 1, "number of migrations to revert with down")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: goshort migrate [options] up|down|status")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	mustLoadConfig()
+	db.InitDB()
+
+	switch fs.Arg(0) {
+	case "up":
+		applied, err := migrations.Up(db.DB)
+		if err != nil {
+			logger.Fatal("Migration failed", logger.Fields{"error": err})
+		}
+		fmt.Printf("Applied %d migration(s).\n", len(applied))
+	case "down":
+		if *steps < 1 {
+			logger.Fatal("-steps must be at least 1", nil)
+		}
+		reverted, err := migrations.Down(db.DB, *steps)
+		if err != nil {
+			logger.Fatal("Migration failed", logger.Fields{"error": err})
+		}
+		fmt.Printf("Reverted %d migration(s).\n", len(reverted))
+	case "status":
+		statuses, err := migrations.List(db.DB)
+		if err != nil {
+			logger.Fatal("Failed to read migrations", logger.Fields{"error": err})
+		}
+		for _, status := range statuses {
+			if status.AppliedAt == nil {
+				fmt.Printf("pending  %s\n", status.ID)
+				continue
+			}
+			fmt.Printf("applied  %s  %s\n", status.ID, status.AppliedAt.Format("2006-01-02 15:04:05"))
+		}
+	default:
+		fs.Usage()
+		os.Exit(2)
+	}
+}
@@ -2,21 +2,53 @@ package main
 
 import (
 	v1 "GoShort/internal/api/v1"
+	"GoShort/internal/middleware"
+	"GoShort/pkg/config"
 
 	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
 // setupRouter initializes the HTTP router and routes
 func setupRouter() *mux.Router {
 	router := mux.NewRouter()
+	router.Use(middleware.RequestID, middleware.Tracing, middleware.Metrics, middleware.AccessLog)
+
+	// Health checks and metrics come first so that short URLs cannot shadow them, and skip
+	// the domain policy since probes and scrapers rarely use a configured host name
+	router.HandleFunc("/healthz", v1.Healthz).Methods("GET", "HEAD")
+	router.HandleFunc("/readyz", v1.Readyz).Methods("GET", "HEAD")
+	if config.Current().Metrics.Enabled {
+		router.Handle("/metrics", v1.RequireMetricsToken(promhttp.Handler())).Methods("GET")
+	}
+
+	app := router.NewRoute().Subrouter()
+	app.Use(middleware.ConfigSnapshot, v1.EnforceDomainPolicy)
 
 	// V1 Routes
-	apiV1 := router.PathPrefix("/v1").Subrouter()
+	apiV1 := app.PathPrefix("/v1").Subrouter()
+	apiV1.Use(middleware.NewRateLimiter().Middleware)
 	apiV1.HandleFunc("/shorten", v1.ShortenURL).Methods("POST")
+	apiV1.HandleFunc("/shorten/bulk", v1.ShortenBulk).Methods("POST")
 	apiV1.HandleFunc("/config", v1.GetConfig).Methods("GET") // Add config endpoint
+	apiV1.HandleFunc("/urls/{shortURL}", v1.GetURL).Methods("GET")
+	apiV1.HandleFunc("/urls/{shortURL}/variants", v1.GetVariants).Methods("GET")
+	apiV1.HandleFunc("/urls/{shortURL}/qr", v1.GetQRCode).Methods("GET")
+
+	// Admin Routes
+	admin := apiV1.PathPrefix("/admin").Subrouter()
+	admin.Use(v1.RequireAdmin)
+	admin.HandleFunc("/import", v1.ImportURLs).Methods("POST")
+	admin.HandleFunc("/export/links", v1.ExportLinks).Methods("GET")
+	admin.HandleFunc("/export/clicks", v1.ExportClicks).Methods("GET")
+	admin.HandleFunc("/domains", v1.ListDomains).Methods("GET")
+	admin.HandleFunc("/domains", v1.SaveDomain).Methods("POST")
+	admin.HandleFunc("/domains/{host}", v1.DeleteDomain).Methods("DELETE")
+	admin.HandleFunc("/cache", v1.GetCacheStats).Methods("GET")
+	admin.HandleFunc("/cache", v1.PurgeCache).Methods("DELETE")
 
 	// Redirect Route (catch-all)
-	router.HandleFunc("/{shortURL}", v1.RedirectURL).Methods("GET")
+	app.HandleFunc("/{shortURL}", v1.RedirectURL).Methods("GET")
 
 	return router
 }
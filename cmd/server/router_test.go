@@ -0,0 +1,35 @@
+package main
+
+import (
+	"GoShort/pkg/config"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHealthRoutes(t *testing.T) {
+	cfg := config.Default()
+	cfg.Domains = config.DomainsConfig{Strict: true, Hosts: []string{"short.example.com"}}
+	config.Set(cfg)
+	t.Cleanup(func() { config.Set(config.Default()) })
+	router := setupRouter()
+
+	// Probes reach the health checks by IP address despite the strict domain policy,
+	// and the checks are not taken for short URLs
+	for _, path := range []string{"/healthz", "/readyz"} {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Host = "10.0.0.7:8080"
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code, path)
+		assert.Equal(t, "application/json", w.Header().Get("Content-Type"), path)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/promo", nil)
+	req.Host = "10.0.0.7:8080"
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusMisdirectedRequest, w.Code)
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"GoShort/pkg/config"
+	"GoShort/pkg/logger"
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// listener is a server together with the function that starts it, such as server.ListenAndServe
+type listener struct {
+	server *http.Server
+	listen func() error
+}
+
+// newServer creates the HTTP server with the configured timeouts
+func newServer(cfg config.ServerConfig, handler http.Handler) *http.Server {
+	return &http.Server{
+		Addr:              cfg.Addr,
+		Handler:           handler,
+		ReadHeaderTimeout: time.Duration(cfg.ReadHeaderTimeout),
+		ReadTimeout:       time.Duration(cfg.ReadTimeout),
+		WriteTimeout:      time.Duration(cfg.WriteTimeout),
+		IdleTimeout:       time.Duration(cfg.IdleTimeout),
+	}
+}
+
+// serve runs the listeners until ctx is done or one of them fails to start. It then stops
+// accepting connections and waits up to shutdownTimeout for in-flight requests to finish.
+// The returned deadline is when that timeout ends, which the rest of the shutdown shares.
+func serve(ctx context.Context, shutdownTimeout time.Duration, listeners ...listener) (time.Time, error) {
+	errs := make(chan error, len(listeners))
+	for _, l := range listeners {
+		go func(l listener) {
+			errs <- l.listen()
+		}(l)
+	}
+
+	var startErr error
+	running := len(listeners)
+	select {
+	case startErr = <-errs:
+		// A server could not start, e.g. because the address is in use
+		running--
+	case <-ctx.Done():
+	}
+
+	logger.Info("Shutting down, draining in-flight requests", nil)
+	deadline := time.Now().Add(shutdownTimeout)
+	shutdownCtx, cancel := context.WithDeadline(context.Background(), deadline)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	shutdownErrs := make([]error, len(listeners))
+	for i, l := range listeners {
+		wg.Add(1)
+		go func(i int, server *http.Server) {
+			defer wg.Done()
+			shutdownErrs[i] = server.Shutdown(shutdownCtx)
+		}(i, l.server)
+	}
+	wg.Wait()
+
+	result := []error{startErr}
+	result = append(result, shutdownErrs...)
+	for ; running > 0; running-- {
+		if err := <-errs; !errors.Is(err, http.ErrServerClosed) {
+			result = append(result, err)
+		}
+	}
+	return deadline, errors.Join(result...)
+}
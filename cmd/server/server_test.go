@@ -0,0 +1,85 @@
+package main
+
+import (
+	"GoShort/pkg/config"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServeDrainsInFlightRequests(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		io.WriteString(w, "done")
+	})
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	server := &http.Server{Handler: handler}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() {
+		_, err := serve(ctx, 5*time.Second, listener{server, func() error { return server.Serve(ln) }})
+		served <- err
+	}()
+
+	responses := make(chan string, 1)
+	go func() {
+		resp, err := http.Get("http://" + ln.Addr().String())
+		if err != nil {
+			responses <- err.Error()
+			return
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		responses <- string(body)
+	}()
+
+	// Shut down while the request is still being handled
+	<-started
+	cancel()
+	time.Sleep(50 * time.Millisecond)
+	select {
+	case err := <-served:
+		t.Fatalf("serve returned before the request finished: %v", err)
+	default:
+	}
+
+	close(release)
+	assert.Equal(t, "done", <-responses)
+	assert.NoError(t, <-served)
+}
+
+func TestServeReportsListenErrors(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer ln.Close()
+
+	// The second server cannot bind, so the first one must be shut down too
+	running := newServer(config.Default().Server, http.NotFoundHandler())
+	running.Addr = "127.0.0.1:0"
+	taken := newServer(config.Default().Server, http.NotFoundHandler())
+	taken.Addr = ln.Addr().String()
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := serve(context.Background(), time.Second,
+			listener{running, running.ListenAndServe}, listener{taken, taken.ListenAndServe})
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		assert.Error(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("serve kept running after a server failed to start")
+	}
+}
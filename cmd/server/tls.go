@@ -0,0 +1,134 @@
+package main
+
+import (
+	v1 "GoShort/internal/api/v1"
+	"GoShort/pkg/config"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"slices"
+
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// tlsListeners returns the HTTPS listener and the plain HTTP listener, which answers ACME
+// HTTP-01 challenges and redirects everything else to HTTPS
+func tlsListeners(cfg *config.Config, router http.Handler) ([]listener, error) {
+	tlsConfig, manager, err := newTLSConfig(cfg.TLS)
+	if err != nil {
+		return nil, err
+	}
+
+	httpsServer := newServer(cfg.Server, router)
+	httpsServer.Addr = cfg.TLS.Addr
+	httpsServer.TLSConfig = tlsConfig
+	httpServer := newServer(cfg.Server, httpHandler(cfg.TLS, manager, router))
+
+	return []listener{
+		{httpsServer, func() error { return httpsServer.ListenAndServeTLS("", "") }},
+		{httpServer, httpServer.ListenAndServe},
+	}, nil
+}
+
+// newTLSConfig builds the TLS configuration from the static certificate files, ACME or both.
+// The returned manager is nil when ACME is disabled.
+func newTLSConfig(cfg config.TLSConfig) (*tls.Config, *autocert.Manager, error) {
+	var static *tls.Certificate
+	if cfg.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to load TLS certificate: %w", err)
+		}
+		static = &cert
+	}
+
+	if !cfg.ACME.Enabled {
+		return &tls.Config{MinVersion: tls.VersionTLS12, Certificates: []tls.Certificate{*static}}, nil, nil
+	}
+
+	manager, err := newCertManager(cfg.ACME)
+	if err != nil {
+		return nil, nil, err
+	}
+	tlsConfig := manager.TLSConfig()
+	tlsConfig.MinVersion = tls.VersionTLS12
+	if static != nil {
+		// Prefer the static certificate for the hosts it covers and let ACME handle the rest
+		fromACME := tlsConfig.GetCertificate
+		tlsConfig.GetCertificate = func(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+			if !slices.Contains(hello.SupportedProtos, acme.ALPNProto) && hello.SupportsCertificate(static) == nil {
+				return static, nil
+			}
+			return fromACME(hello)
+		}
+	}
+	return tlsConfig, manager, nil
+}
+
+// newCertManager creates the manager obtaining certificates for the configured hosts and
+// registered custom domains
+func newCertManager(cfg config.ACMEConfig) (*autocert.Manager, error) {
+	client := &acme.Client{DirectoryURL: cfg.DirectoryURL}
+	if cfg.CAFile != "" {
+		// Trust the CA of a private directory such as Pebble
+		pem, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read ACME CA file: %w", err)
+		}
+		roots, err := x509.SystemCertPool()
+		if err != nil {
+			roots = x509.NewCertPool()
+		}
+		if !roots.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in ACME CA file %s", cfg.CAFile)
+		}
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = &tls.Config{RootCAs: roots}
+		client.HTTPClient = &http.Client{Transport: transport}
+	}
+
+	return &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		Cache:      autocert.DirCache(cfg.CacheDir),
+		HostPolicy: v1.CertificateHostPolicy,
+		Client:     client,
+		Email:      cfg.Email,
+	}, nil
+}
+
+// httpHandler is the handler of the plain HTTP listener when TLS is enabled
+func httpHandler(cfg config.TLSConfig, manager *autocert.Manager, router http.Handler) http.Handler {
+	handler := router
+	if cfg.RedirectHTTP {
+		// Keep the health checks reachable over plain HTTP for probes
+		mux := http.NewServeMux()
+		mux.Handle("/healthz", router)
+		mux.Handle("/readyz", router)
+		mux.Handle("/", redirectToHTTPS(cfg.Addr))
+		handler = mux
+	}
+	if manager != nil {
+		// Answer HTTP-01 challenges before anything else
+		handler = manager.HTTPHandler(handler)
+	}
+	return handler
+}
+
+// redirectToHTTPS permanently redirects requests to the same URL on the HTTPS listener
+func redirectToHTTPS(tlsAddr string) http.Handler {
+	_, port, _ := net.SplitHostPort(tlsAddr)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if port != "" && port != "443" {
+			host = net.JoinHostPort(host, port)
+		}
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusMovedPermanently)
+	})
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"GoShort/pkg/config"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// writeCertificate writes a self-signed certificate for host and its key into a temporary directory
+func writeCertificate(t *testing.T, host string) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: host},
+		DNSNames:     []string{host},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	assert.NoError(t, err)
+
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	assert.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o644))
+	assert.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+	return certFile, keyFile
+}
+
+func TestNewTLSConfigStatic(t *testing.T) {
+	certFile, keyFile := writeCertificate(t, "short.example.com")
+	tlsConfig, manager, err := newTLSConfig(config.TLSConfig{CertFile: certFile, KeyFile: keyFile})
+	assert.NoError(t, err)
+	assert.Nil(t, manager)
+	assert.Len(t, tlsConfig.Certificates, 1)
+	assert.Equal(t, uint16(tls.VersionTLS12), tlsConfig.MinVersion)
+
+	_, _, err = newTLSConfig(config.TLSConfig{CertFile: certFile, KeyFile: certFile})
+	assert.Error(t, err)
+}
+
+func TestNewTLSConfigStaticWithACME(t *testing.T) {
+	config.Set(config.Default())
+	certFile, keyFile := writeCertificate(t, "short.example.com")
+	tlsConfig, manager, err := newTLSConfig(config.TLSConfig{
+		CertFile: certFile,
+		KeyFile:  keyFile,
+		ACME:     config.ACMEConfig{Enabled: true, CacheDir: t.TempDir()},
+	})
+	assert.NoError(t, err)
+	assert.NotNil(t, manager)
+
+	// The static certificate covers its host; other hosts go to ACME, which refuses unknown ones
+	cert, err := tlsConfig.GetCertificate(&tls.ClientHelloInfo{
+		ServerName:        "short.example.com",
+		SupportedVersions: []uint16{tls.VersionTLS13},
+		SignatureSchemes:  []tls.SignatureScheme{tls.ECDSAWithP256AndSHA256},
+	})
+	assert.NoError(t, err)
+	assert.NotNil(t, cert)
+	_, err = tlsConfig.GetCertificate(&tls.ClientHelloInfo{ServerName: "unknown.example.com"})
+	assert.Error(t, err)
+}
+
+func TestNewCertManagerRejectsInvalidCAFile(t *testing.T) {
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	assert.NoError(t, os.WriteFile(caFile, []byte("not a certificate"), 0o644))
+	_, err := newCertManager(config.ACMEConfig{Enabled: true, CacheDir: t.TempDir(), CAFile: caFile})
+	assert.Error(t, err)
+}
+
+func TestRedirectToHTTPS(t *testing.T) {
+	tests := []struct {
+		tlsAddr  string
+		host     string
+		expected string
+	}{
+		{":443", "short.example.com", "https://short.example.com/promo?utm=1"},
+		{":443", "short.example.com:80", "https://short.example.com/promo?utm=1"},
+		{":8443", "short.example.com:8080", "https://short.example.com:8443/promo?utm=1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.tlsAddr+" "+tt.host, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/promo?utm=1", nil)
+			req.Host = tt.host
+			w := httptest.NewRecorder()
+			redirectToHTTPS(tt.tlsAddr).ServeHTTP(w, req)
+			assert.Equal(t, http.StatusMovedPermanently, w.Code)
+			assert.Equal(t, tt.expected, w.Header().Get("Location"))
+		})
+	}
+}
+
+func TestHTTPHandler(t *testing.T) {
+	router := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+	serve := func(handler http.Handler, path string) int {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w.Code
+	}
+
+	cfg := config.Default().TLS
+	cfg.ACME = config.ACMEConfig{Enabled: true, CacheDir: t.TempDir()}
+	manager, err := newCertManager(cfg.ACME)
+	assert.NoError(t, err)
+
+	handler := httpHandler(cfg, manager, router)
+	assert.Equal(t, http.StatusMovedPermanently, serve(handler, "/promo"))
+	assert.NotEqual(t, http.StatusMovedPermanently, serve(handler, "/.well-known/acme-challenge/token"),
+		"Challenges must be answered over plain HTTP")
+	assert.Equal(t, http.StatusTeapot, serve(handler, "/healthz"), "Health checks must be served over plain HTTP")
+	assert.Equal(t, http.StatusTeapot, serve(handler, "/readyz"))
+
+	cfg.RedirectHTTP = false
+	assert.Equal(t, http.StatusTeapot, serve(httpHandler(cfg, nil, router), "/promo"))
+}
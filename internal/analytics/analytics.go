@@ -1,7 +1,7 @@
 package analytics
 
 import (
-	"log"
+	"GoShort/pkg/logger"
 	"time"
 )
 
@@ -11,9 +11,9 @@ func StartCleanupTask(tracker *AnalyticsTracker, interval time.Duration, cutoff
 		for {
 			time.Sleep(interval)
 
-			log.Println("Running analytics cleanup task...")
+			logger.Info("Running analytics cleanup task", nil)
 			tracker.CleanupOldStats(time.Now().Add(-cutoff))
-			log.Println("Analytics cleanup task completed.")
+			logger.Info("Analytics cleanup task completed", nil)
 		}
 	}()
 }
@@ -0,0 +1,181 @@
+package analytics
+
+import (
+	"GoShort/internal/models"
+	"GoShort/pkg/logger"
+	"context"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// recorderQueueSize is the number of click events buffered before new ones are dropped
+	recorderQueueSize = 10000
+	// recorderBatchSize is the maximum number of click events written per insert
+	recorderBatchSize = 500
+	// recorderFlushInterval is how often buffered click events are written
+	recorderFlushInterval = time.Second
+)
+
+// Recorder counts clicks, and stores click events when asked to, in the background so that
+// redirects never wait on the database
+type Recorder struct {
+	db      *gorm.DB
+	events  bool // Store every click as a ClickEvent besides counting it
+	queue   chan models.ClickEvent
+	dropped atomic.Uint64
+	// Clicks per link and variant not written yet, kept until a flush succeeds. Only touched
+	// by the flush loop.
+	urls      map[uint]int
+	variants  map[uint]int
+	wg        sync.WaitGroup
+	closeOnce sync.Once
+	closeCtx  context.Context // Bounds the final flush, set by Close before closing the queue
+}
+
+// NewRecorder creates a Recorder writing to db and starts its flush loop. Click events are
+// stored when events is set; clicks are counted either way.
+func NewRecorder(db *gorm.DB, events bool) *Recorder {
+	r := &Recorder{
+		db:       db,
+		events:   events,
+		queue:    make(chan models.ClickEvent, recorderQueueSize),
+		urls:     make(map[uint]int),
+		variants: make(map[uint]int),
+	}
+	r.wg.Add(1)
+	go r.run()
+	return r
+}
+
+// Record queues a click. Clicks are dropped when the queue is full.
+func (r *Recorder) Record(event models.ClickEvent) {
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now()
+	}
+	select {
+	case r.queue <- event:
+	default:
+		r.dropped.Add(1)
+	}
+}
+
+// QueueDepth returns the number of click events waiting to be written
+func (r *Recorder) QueueDepth() int {
+	return len(r.queue)
+}
+
+// Dropped returns the number of click events dropped because the queue was full
+func (r *Recorder) Dropped() uint64 {
+	return r.dropped.Load()
+}
+
+// Close stops accepting click events and writes the pending ones, giving up when ctx is done
+func (r *Recorder) Close(ctx context.Context) error {
+	r.closeOnce.Do(func() {
+		r.closeCtx = ctx
+		close(r.queue)
+	})
+
+	flushed := make(chan struct{})
+	go func() {
+		r.wg.Wait()
+		close(flushed)
+	}()
+	select {
+	case <-flushed:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// run batches queued click events and writes them until the queue is closed
+func (r *Recorder) run() {
+	defer r.wg.Done()
+
+	ticker := time.NewTicker(recorderFlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]models.ClickEvent, 0, recorderBatchSize)
+	for {
+		select {
+		case event, ok := <-r.queue:
+			if !ok {
+				r.db = r.db.WithContext(r.closeCtx)
+				r.flush(batch)
+				return
+			}
+			batch = append(batch, event)
+			if len(batch) == recorderBatchSize {
+				r.flush(batch)
+				batch = batch[:0]
+			}
+		case <-ticker.C:
+			// Also retry clicks whose counting failed when no new ones came in
+			if len(batch) > 0 || len(r.urls) > 0 {
+				r.flush(batch)
+				batch = batch[:0]
+			}
+		}
+	}
+}
+
+// flush writes a batch of click events and adds them to the click counters. Clicks that
+// could not be counted are kept and counted with the next batch.
+func (r *Recorder) flush(batch []models.ClickEvent) {
+	if r.events && len(batch) > 0 {
+		if err := r.db.CreateInBatches(batch, recorderBatchSize).Error; err != nil {
+			logger.Error("Failed to record click events", logger.Fields{"count": len(batch), "error": err})
+		}
+	}
+
+	for _, event := range batch {
+		r.urls[event.URLID]++
+		if event.VariantID != nil {
+			r.variants[*event.VariantID]++
+		}
+	}
+	if len(r.urls) == 0 {
+		return
+	}
+	if err := r.count(); err != nil {
+		logger.Error("Failed to count clicks, retrying with the next batch", logger.Fields{"links": len(r.urls), "error": err})
+		return
+	}
+	clear(r.urls)
+	clear(r.variants)
+}
+
+// count adds the pending clicks to the counters of their links and variants, with one update
+// per link or variant. Rows are updated in the order of their IDs so that concurrent flushes
+// from several instances cannot deadlock.
+func (r *Recorder) count() error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		for _, id := range sortedIDs(r.variants) {
+			if err := tx.Model(&models.URLVariant{}).Where("id = ?", id).UpdateColumn("clicks", gorm.Expr("clicks + ?", r.variants[id])).Error; err != nil {
+				return err
+			}
+		}
+		for _, id := range sortedIDs(r.urls) {
+			if err := tx.Model(&models.URL{}).Where("id = ?", id).UpdateColumn("clicks", gorm.Expr("clicks + ?", r.urls[id])).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// sortedIDs returns the IDs counted in clicks in increasing order
+func sortedIDs(clicks map[uint]int) []uint {
+	ids := make([]uint, 0, len(clicks))
+	for id := range clicks {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	return ids
+}
@@ -0,0 +1,89 @@
+package analytics
+
+import (
+	"GoShort/internal/models"
+	"GoShort/migrations"
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newSQLiteDB opens a fresh, migrated SQLite database
+func newSQLiteDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "goshort.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	_, err = migrations.Up(db)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+	})
+	return db
+}
+
+func TestRecorderCountsClicks(t *testing.T) {
+	for _, events := range []bool{true, false} {
+		db := newSQLiteDB(t)
+		url := models.URL{ShortURL: "promo", LongURL: "https://example.com", Variants: []models.URLVariant{{LongURL: "https://example.com/b", Weight: 1}}}
+		require.NoError(t, db.Create(&url).Error)
+
+		recorder := NewRecorder(db, events)
+		for i := 0; i < 3; i++ {
+			recorder.Record(models.ClickEvent{URLID: url.ID, ShortURL: url.ShortURL})
+		}
+		recorder.Record(models.ClickEvent{URLID: url.ID, ShortURL: url.ShortURL, VariantID: &url.Variants[0].ID})
+		require.NoError(t, recorder.Close(context.Background()))
+
+		var stored models.URL
+		require.NoError(t, db.Preload("Variants").First(&stored, url.ID).Error)
+		assert.Equal(t, 4, stored.Clicks)
+		assert.Equal(t, 1, stored.Variants[0].Clicks)
+
+		var count int64
+		require.NoError(t, db.Model(&models.ClickEvent{}).Count(&count).Error)
+		if events {
+			assert.EqualValues(t, 4, count)
+		} else {
+			assert.Zero(t, count, "Click events are only stored with analytics on")
+		}
+	}
+}
+
+func TestRecorderRetriesFailedCounts(t *testing.T) {
+	db := newSQLiteDB(t)
+	url := models.URL{ShortURL: "promo", LongURL: "https://example.com"}
+	require.NoError(t, db.Create(&url).Error)
+
+	// Fail the updates of the first flush only
+	failing := true
+	require.NoError(t, db.Callback().Update().Before("gorm:update").Register("test:fail", func(tx *gorm.DB) {
+		if failing {
+			tx.AddError(errors.New("deadlock detected"))
+		}
+	}))
+
+	recorder := &Recorder{db: db, urls: make(map[uint]int), variants: make(map[uint]int)}
+	recorder.flush([]models.ClickEvent{{URLID: url.ID}, {URLID: url.ID}})
+	var stored models.URL
+	require.NoError(t, db.First(&stored, url.ID).Error)
+	assert.Zero(t, stored.Clicks)
+
+	failing = false
+	recorder.flush([]models.ClickEvent{{URLID: url.ID}})
+	require.NoError(t, db.First(&stored, url.ID).Error)
+	assert.Equal(t, 3, stored.Clicks, "Clicks of the failed flush are counted with the next one")
+	assert.Empty(t, recorder.urls)
+}
+
+func TestSortedIDs(t *testing.T) {
+	assert.Equal(t, []uint{1, 5, 9}, sortedIDs(map[uint]int{9: 1, 1: 2, 5: 3}))
+}
@@ -0,0 +1,49 @@
+package v1
+
+import (
+	"GoShort/pkg/config"
+	"crypto/subtle"
+	"net/http"
+	"strings"
+)
+
+// RequireAdmin protects admin endpoints with the configured bearer token (ADMIN_TOKEN).
+// Admin endpoints are disabled when no token is configured.
+func RequireAdmin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := config.FromContext(r.Context()).Admin.Token
+		if token == "" {
+			http.Error(w, "Admin API is disabled", http.StatusForbidden)
+			return
+		}
+
+		if !hasBearerToken(r, token) {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="goshort-admin"`)
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// RequireMetricsToken protects the metrics endpoint with the configured bearer token
+// (METRICS_TOKEN). The endpoint is open when no token is configured.
+func RequireMetricsToken(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := config.Current().Metrics.Token
+		if token != "" && !hasBearerToken(r, token) {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="goshort-metrics"`)
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// hasBearerToken reports whether the request is authorized with token, in constant time
+func hasBearerToken(r *http.Request, token string) bool {
+	provided, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(provided), []byte(token)) == 1
+}
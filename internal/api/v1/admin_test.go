@@ -0,0 +1,34 @@
+package v1
+
+import (
+	"GoShort/pkg/config"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequireMetricsToken(t *testing.T) {
+	handler := RequireMetricsToken(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	scrape := func(authorization string) int {
+		req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w.Code
+	}
+	t.Cleanup(func() { config.Set(config.Default()) })
+
+	config.Set(config.Default())
+	assert.Equal(t, http.StatusOK, scrape(""), "Expected metrics to be open without a token")
+
+	cfg := config.Default()
+	cfg.Metrics.Token = "secret"
+	config.Set(cfg)
+	assert.Equal(t, http.StatusUnauthorized, scrape(""))
+	assert.Equal(t, http.StatusUnauthorized, scrape("Bearer wrong"))
+	assert.Equal(t, http.StatusOK, scrape("Bearer secret"))
+}
@@ -0,0 +1,457 @@
+package v1
+
+import (
+	"GoShort/internal/db"
+	"GoShort/internal/metrics"
+	"GoShort/internal/models"
+	"GoShort/internal/utils"
+	"GoShort/pkg/config"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// maxBulkItems limits the number of items in a JSON array request; use NDJSON for more
+	maxBulkItems = 1000
+	// bulkBatchSize is the number of items inserted per transaction
+	bulkBatchSize = 100
+	// maxBulkBodyBytes limits the size of a JSON array request body
+	maxBulkBodyBytes = 10 << 20
+	// scanWorkers is the number of items of a batch scanned at the same time
+	scanWorkers = 8
+	// bulkScanTimeout bounds the time spent scanning a whole batch
+	bulkScanTimeout = 30 * time.Second
+)
+
+// ndjsonContentType is the media type of newline-delimited JSON streams
+const ndjsonContentType = "application/x-ndjson"
+
+// BulkResult represents the outcome of one item of a bulk shorten request
+type BulkResult struct {
+	Index    int    `json:"index"`
+	Status   int    `json:"status"`
+	ShortURL string `json:"short_url,omitempty"`
+	Code     string `json:"code,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// BulkResponse represents the response payload of a JSON array bulk shorten request
+type BulkResponse struct {
+	Results   []BulkResult `json:"results"`
+	Succeeded int          `json:"succeeded"`
+	Failed    int          `json:"failed"`
+}
+
+// errorResult converts a shortenError into the result of item index
+func errorResult(index int, err *shortenError) BulkResult {
+	return BulkResult{Index: index, Status: err.Status, Code: err.Code, Error: err.Message}
+}
+
+// domainResolver resolves the namespace of each bulk item, remembering domains already looked up
+type domainResolver struct {
+	r     *http.Request
+	hosts map[string]string
+}
+
+// newDomainResolver creates a domainResolver for the items of a bulk request
+func newDomainResolver(r *http.Request) *domainResolver {
+	return &domainResolver{r: r, hosts: make(map[string]string)}
+}
+
+// resolve returns the namespace an item requesting the given domain is stored in
+func (d *domainResolver) resolve(requested string) (string, *shortenError) {
+	key := normalizeHost(requested)
+	if host, ok := d.hosts[key]; ok {
+		return host, nil
+	}
+	host, err := resolveShortenDomain(d.r, requested)
+	if err == nil {
+		d.hosts[key] = host
+	}
+	return host, err
+}
+
+// namespacedKey identifies a short URL within its domain
+func namespacedKey(domain, shortURL string) string {
+	return domain + "/" + shortURL
+}
+
+// shortenBatch validates and stores a batch of shorten requests in a single transaction.
+// offset is the index of the first request within the whole bulk request.
+func shortenBatch(ctx context.Context, cfg *config.Config, reqs []ShortenRequest, offset int, domains *domainResolver) []BulkResult {
+	results := make([]BulkResult, len(reqs))
+	urls := make([]*models.URL, len(reqs))
+	// Every return fills in this same slice, so it can be counted once done
+	defer countBulkResults(results)
+
+	// Validate every item, then scan the valid ones concurrently
+	for i, req := range reqs {
+		url, err := buildURL(req, cfg.Links)
+		if err == nil {
+			url.Domain, err = domains.resolve(req.Domain)
+		}
+		if err != nil {
+			results[i] = errorResult(offset+i, err)
+			continue
+		}
+		urls[i] = url
+	}
+	scanErrs := scanBatch(ctx, urls, cfg.Scanner)
+
+	// Collect the requested custom URLs
+	custom := make(map[string]int)
+	requested := make(map[string][]string)
+	for i, url := range urls {
+		if url == nil {
+			continue
+		}
+		if scanErrs[i] != nil {
+			urls[i] = nil
+			results[i] = errorResult(offset+i, scanErrs[i])
+			continue
+		}
+		if url.ShortURL != "" {
+			key := namespacedKey(url.Domain, url.ShortURL)
+			if _, duplicate := custom[key]; duplicate {
+				urls[i] = nil
+				results[i] = errorResult(offset+i, newShortenError(http.StatusConflict, codeCustomURLTaken, "Custom URL is already taken"))
+				continue
+			}
+			custom[key] = i
+			requested[url.Domain] = append(requested[url.Domain], url.ShortURL)
+		}
+	}
+
+	// Reject custom URLs that already exist with a single query
+	if len(requested) > 0 {
+		taken, err := takenShortURLs(ctx, requested)
+		if err != nil {
+			return failPending(results, urls, offset)
+		}
+		for key := range taken {
+			i := custom[key]
+			urls[i] = nil
+			results[i] = errorResult(offset+i, newShortenError(http.StatusConflict, codeCustomURLTaken, "Custom URL is already taken"))
+		}
+	}
+
+	// Generate short URLs for the remaining items, avoiding those taken in the batch or the database
+	generated := make([]bool, len(urls))
+	for i, url := range urls {
+		generated[i] = url != nil && url.ShortURL == ""
+	}
+	if err := generateShortURLs(ctx, urls, custom); err != nil {
+		return failPending(results, urls, offset)
+	}
+
+	pending := make([]*models.URL, 0, len(urls))
+	for i, url := range urls {
+		if url == nil {
+			continue
+		}
+		if url.ShortURL == "" {
+			// Every short URL generated for the item was taken
+			urls[i] = nil
+			results[i] = errorResult(offset+i, newShortenError(http.StatusInternalServerError, codeInternalError, "Failed to save URL"))
+			continue
+		}
+		pending = append(pending, url)
+	}
+	if len(pending) == 0 {
+		return results
+	}
+
+	if err := db.DB.WithContext(ctx).Create(&pending).Error; err != nil {
+		// Links created concurrently may collide with some items: store each item on its own
+		// so that only the ones actually failing are reported
+		createEach(ctx, results, urls, generated, offset)
+	}
+
+	for i, url := range urls {
+		if url == nil || results[i].Code != "" {
+			continue
+		}
+		results[i] = BulkResult{Index: offset + i, Status: http.StatusCreated, ShortURL: url.ShortURL}
+
+		// Items are stored without going through Links, so drop misses cached before they existed
+		invalidateLink(ctx, url.Domain, url.ShortURL)
+
+		// Fetch the destination title and favicon in the background
+		if MetadataFetcher != nil {
+			MetadataFetcher.Enqueue(url)
+		}
+	}
+	return results
+}
+
+// scanBatch scans the destinations of the non-nil urls with at most scanWorkers requests in
+// flight, all within bulkScanTimeout, and returns the error of each item
+func scanBatch(ctx context.Context, urls []*models.URL, scanner config.ScannerConfig) []*shortenError {
+	errs := make([]*shortenError, len(urls))
+	if !scanner.Enabled {
+		return errs
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, bulkScanTimeout)
+	defer cancel()
+
+	slots := make(chan struct{}, scanWorkers)
+	var wg sync.WaitGroup
+	for i, url := range urls {
+		if url == nil {
+			continue
+		}
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(i int, url *models.URL) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			errs[i] = scanDestinations(ctx, url, scanner)
+		}(i, url)
+	}
+	wg.Wait()
+	return errs
+}
+
+// takenShortURLs returns the namespaced keys of the requested short URLs, by domain, that
+// already exist. The query is written without row values so that it also runs on SQLite.
+func takenShortURLs(ctx context.Context, requested map[string][]string) (map[string]bool, error) {
+	query := db.DB.WithContext(ctx).Select("domain", "short_url")
+	for domain, shortURLs := range requested {
+		query = query.Or("domain = ? AND short_url IN ?", domain, shortURLs)
+	}
+	var existing []models.URL
+	if err := query.Find(&existing).Error; err != nil {
+		return nil, err
+	}
+	taken := make(map[string]bool, len(existing))
+	for _, url := range existing {
+		taken[namespacedKey(url.Domain, url.ShortURL)] = true
+	}
+	return taken, nil
+}
+
+// generateShortURLs assigns a generated short URL to every item without one, checking them
+// against the database and regenerating those already taken. Items whose every attempt was
+// taken are left without one. used holds the keys taken within the batch and is updated with
+// the generated ones.
+func generateShortURLs(ctx context.Context, urls []*models.URL, used map[string]int) error {
+	for attempt := 0; attempt < generateAttempts; attempt++ {
+		requested := make(map[string][]string)
+		assigned := make(map[string]*models.URL)
+		for _, url := range urls {
+			if url == nil || url.ShortURL != "" {
+				continue
+			}
+			for url.ShortURL == "" {
+				shortURL := utils.GenerateShortURL()
+				key := namespacedKey(url.Domain, shortURL)
+				if _, exists := used[key]; !exists {
+					url.ShortURL = shortURL
+					used[key] = -1
+					assigned[key] = url
+				}
+			}
+			requested[url.Domain] = append(requested[url.Domain], url.ShortURL)
+		}
+		if len(requested) == 0 {
+			return nil
+		}
+
+		taken, err := takenShortURLs(ctx, requested)
+		if err != nil {
+			return err
+		}
+		if len(taken) == 0 {
+			return nil
+		}
+		for key := range taken {
+			assigned[key].ShortURL = ""
+		}
+	}
+	return nil
+}
+
+// createEach stores the items of a batch one at a time after the batch insert failed. Custom
+// URLs taken in the meantime are reported as conflicts, generated ones are regenerated.
+func createEach(ctx context.Context, results []BulkResult, urls []*models.URL, generated []bool, offset int) {
+	for i, url := range urls {
+		if url == nil {
+			continue
+		}
+		err := createItem(ctx, url, generated[i])
+		switch {
+		case errors.Is(err, gorm.ErrDuplicatedKey) && !generated[i]:
+			results[i] = errorResult(offset+i, newShortenError(http.StatusConflict, codeCustomURLTaken, "Custom URL is already taken"))
+		case err != nil:
+			results[i] = errorResult(offset+i, newShortenError(http.StatusInternalServerError, codeInternalError, "Failed to save URL"))
+		}
+	}
+}
+
+// createItem stores a single bulk item, trying new short URLs on collisions when it was generated
+func createItem(ctx context.Context, url *models.URL, generated bool) error {
+	var err error
+	for attempt := 0; attempt < generateAttempts; attempt++ {
+		// The failed batch insert may have assigned IDs that were rolled back
+		resetIDs(url)
+		if err = db.DB.WithContext(ctx).Create(url).Error; !errors.Is(err, gorm.ErrDuplicatedKey) || !generated {
+			return err
+		}
+		url.ShortURL = utils.GenerateShortURL()
+	}
+	return err
+}
+
+// resetIDs clears the IDs of url and its associations so that it can be inserted again
+func resetIDs(url *models.URL) {
+	url.ID = 0
+	for i := range url.Variants {
+		url.Variants[i].ID, url.Variants[i].URLID = 0, 0
+	}
+	for i := range url.Schedules {
+		url.Schedules[i].ID, url.Schedules[i].URLID = 0, 0
+	}
+}
+
+// countBulkResults counts the outcome of each bulk item like single shorten requests
+func countBulkResults(results []BulkResult) {
+	for _, result := range results {
+		if result.Code == "" {
+			metrics.Shortens.WithLabelValues(metrics.ShortenSuccess).Inc()
+		} else {
+			metrics.Shortens.WithLabelValues(result.Code).Inc()
+		}
+	}
+}
+
+// failPending marks every item that passed validation as failed with an internal error
+func failPending(results []BulkResult, urls []*models.URL, offset int) []BulkResult {
+	for i, url := range urls {
+		if url != nil {
+			results[i] = errorResult(offset+i, newShortenError(http.StatusInternalServerError, codeInternalError, "Failed to save URL"))
+		}
+	}
+	return results
+}
+
+// ShortenBulk handles shortening many URLs in one request. It accepts a JSON array of
+// ShortenRequest items, or an NDJSON stream of them which is answered with an NDJSON
+// stream of results so that arbitrarily large imports never have to fit in memory.
+func ShortenBulk(w http.ResponseWriter, r *http.Request) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == ndjsonContentType {
+		shortenBulkStream(w, r)
+		return
+	}
+
+	var reqs []ShortenRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBulkBodyBytes)).Decode(&reqs); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+	if len(reqs) == 0 {
+		http.Error(w, "No URLs to shorten", http.StatusBadRequest)
+		return
+	}
+	if len(reqs) > maxBulkItems {
+		http.Error(w, "Too many URLs, use an NDJSON stream for large batches", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	cfg := config.FromContext(r.Context())
+	domains := newDomainResolver(r)
+	resp := BulkResponse{Results: make([]BulkResult, 0, len(reqs))}
+	for start := 0; start < len(reqs); start += bulkBatchSize {
+		end := start + bulkBatchSize
+		if end > len(reqs) {
+			end = len(reqs)
+		}
+		resp.Results = append(resp.Results, shortenBatch(r.Context(), cfg, reqs[start:end], start, domains)...)
+	}
+	for _, result := range resp.Results {
+		if result.Error == "" {
+			resp.Succeeded++
+		} else {
+			resp.Failed++
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// shortenBulkStream processes an NDJSON stream batch by batch, writing results as it goes
+func shortenBulkStream(w http.ResponseWriter, r *http.Request) {
+	cfg := config.FromContext(r.Context())
+
+	// Streams may run for longer than the server's read and write timeouts allow. Rather than
+	// lifting them, which would let a slow client hold the connection forever, every batch
+	// gets the full timeouts to arrive and to be answered.
+	rc := http.NewResponseController(w)
+	// Results are written while the rest of the stream is still being read
+	rc.EnableFullDuplex()
+	extend := func(setDeadline func(time.Time) error, timeout config.Duration) {
+		if timeout > 0 {
+			setDeadline(time.Now().Add(time.Duration(timeout)))
+		}
+	}
+
+	w.Header().Set("Content-Type", ndjsonContentType)
+	encoder := json.NewEncoder(w)
+	flusher, _ := w.(http.Flusher)
+
+	domains := newDomainResolver(r)
+	decoder := json.NewDecoder(r.Body)
+	batch := make([]ShortenRequest, 0, bulkBatchSize)
+	index, offset := 0, 0
+
+	flush := func() {
+		results := shortenBatch(r.Context(), cfg, batch, offset, domains)
+		extend(rc.SetWriteDeadline, cfg.Server.WriteTimeout)
+		for _, result := range results {
+			encoder.Encode(result)
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		extend(rc.SetReadDeadline, cfg.Server.ReadTimeout)
+		offset = index
+		batch = batch[:0]
+	}
+
+	for {
+		var req ShortenRequest
+		err := decoder.Decode(&req)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			// A malformed line ends the stream since the decoder cannot resynchronise
+			if len(batch) > 0 {
+				flush()
+			}
+			extend(rc.SetWriteDeadline, cfg.Server.WriteTimeout)
+			encoder.Encode(errorResult(index, newShortenError(http.StatusBadRequest, codeInvalidPayload, "Invalid request payload")))
+			return
+		}
+
+		batch = append(batch, req)
+		index++
+		if len(batch) == bulkBatchSize {
+			flush()
+		}
+	}
+
+	if len(batch) > 0 {
+		flush()
+	}
+}
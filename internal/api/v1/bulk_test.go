@@ -0,0 +1,274 @@
+package v1
+
+import (
+	"GoShort/internal/models"
+	"GoShort/internal/store"
+	"GoShort/internal/utils"
+	"GoShort/pkg/config"
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildURLErrorCodes(t *testing.T) {
+	tests := []struct {
+		name         string
+		req          ShortenRequest
+		expectedCode string
+	}{
+		{"Invalid long URL", ShortenRequest{LongURL: "invalid-url"}, codeInvalidURL},
+		{"Invalid custom URL", ShortenRequest{LongURL: "https://example.com", CustomURL: "has space"}, codeInvalidCustomURL},
+		{"Invalid expiry", ShortenRequest{LongURL: "https://example.com", Expiry: "not-a-date"}, codeInvalidExpiry},
+		{"Invalid variants", ShortenRequest{Variants: []VariantRequest{{LongURL: "invalid-url"}}}, codeInvalidVariants},
+		{"Invalid OpenGraph image", ShortenRequest{LongURL: "https://example.com", OGImage: "invalid-url"}, codeInvalidMetadata},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			url, err := buildURL(tt.req, config.LinksConfig{})
+			assert.Nil(t, url)
+			assert.NotNil(t, err)
+			assert.Equal(t, http.StatusBadRequest, err.Status)
+			assert.Equal(t, tt.expectedCode, err.Code)
+		})
+	}
+
+	url, err := buildURL(ShortenRequest{LongURL: "https://example.com", CustomURL: "promo"}, config.LinksConfig{})
+	assert.Nil(t, err)
+	assert.Equal(t, "promo", url.ShortURL)
+}
+
+func TestShortenBulkRejectsInvalidItems(t *testing.T) {
+	body := `[{"long_url":"invalid-url"},{"long_url":"https://example.com","custom_url":"bad url"}]`
+	req := httptest.NewRequest(http.MethodPost, "/v1/shorten/bulk", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	ShortenBulk(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var resp BulkResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, 0, resp.Succeeded)
+	assert.Equal(t, 2, resp.Failed)
+	assert.Equal(t, 0, resp.Results[0].Index)
+	assert.Equal(t, codeInvalidURL, resp.Results[0].Code)
+	assert.Equal(t, 1, resp.Results[1].Index)
+	assert.Equal(t, codeInvalidCustomURL, resp.Results[1].Code)
+}
+
+func TestShortenBulkEmpty(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/v1/shorten/bulk", strings.NewReader(`[]`))
+	w := httptest.NewRecorder()
+
+	ShortenBulk(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestShortenBulkStream(t *testing.T) {
+	body := "{\"long_url\":\"invalid-url\"}\n{\"long_url\":\"ftp:/broken\"}\nnot json\n"
+	req := httptest.NewRequest(http.MethodPost, "/v1/shorten/bulk", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	w := httptest.NewRecorder()
+
+	ShortenBulk(w, req)
+
+	assert.Equal(t, "application/x-ndjson", w.Header().Get("Content-Type"))
+
+	var results []BulkResult
+	scanner := bufio.NewScanner(w.Body)
+	for scanner.Scan() {
+		var result BulkResult
+		assert.NoError(t, json.Unmarshal(scanner.Bytes(), &result))
+		results = append(results, result)
+	}
+
+	assert.Len(t, results, 3)
+	assert.Equal(t, codeInvalidURL, results[0].Code)
+	assert.Equal(t, codeInvalidURL, results[1].Code)
+	assert.Equal(t, 2, results[2].Index)
+	assert.Equal(t, codeInvalidPayload, results[2].Code)
+}
+
+func TestShortenBulkStreamDeadlines(t *testing.T) {
+	cfg := config.Default()
+	cfg.Server.ReadTimeout = config.Duration(500 * time.Millisecond)
+	cfg.Server.WriteTimeout = config.Duration(500 * time.Millisecond)
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ShortenBulk(w, r.WithContext(config.WithContext(r.Context(), cfg)))
+	}))
+	server.Config.ReadTimeout = time.Duration(cfg.Server.ReadTimeout)
+	server.Config.WriteTimeout = time.Duration(cfg.Server.WriteTimeout)
+	server.Start()
+	defer server.Close()
+
+	// stream posts the lines written to the returned pipe and decodes the results
+	stream := func() (*io.PipeWriter, <-chan []BulkResult) {
+		body, writer := io.Pipe()
+		done := make(chan []BulkResult, 1)
+		go func() {
+			var results []BulkResult
+			defer func() { done <- results }()
+			resp, err := http.Post(server.URL, ndjsonContentType, body)
+			if err != nil {
+				return
+			}
+			defer resp.Body.Close()
+			decoder := json.NewDecoder(resp.Body)
+			for {
+				var result BulkResult
+				if decoder.Decode(&result) != nil {
+					return
+				}
+				results = append(results, result)
+			}
+		}()
+		return writer, done
+	}
+	line := "{\"long_url\":\"invalid-url\"}\n"
+
+	// A stream outlasting the read timeout is fine as long as every batch arrives in time
+	writer, done := stream()
+	time.Sleep(300 * time.Millisecond)
+	io.WriteString(writer, strings.Repeat(line, bulkBatchSize))
+	time.Sleep(300 * time.Millisecond)
+	io.WriteString(writer, line)
+	writer.Close()
+	results := <-done
+	require.Len(t, results, bulkBatchSize+1)
+	assert.Equal(t, codeInvalidURL, results[bulkBatchSize].Code)
+
+	// A client stalling mid-batch is cut off instead of holding the connection
+	writer, done = stream()
+	defer writer.Close()
+	io.WriteString(writer, line)
+	select {
+	case results = <-done:
+		require.Len(t, results, 2)
+		assert.Equal(t, codeInvalidURL, results[0].Code)
+		assert.Equal(t, codeInvalidPayload, results[1].Code)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Stalled stream was not cut off")
+	}
+}
+
+func TestShortenBulkReportsOnlyTakenCustomURLs(t *testing.T) {
+	conn := useSQLiteDB(t)
+	require.NoError(t, conn.Create(&models.URL{ShortURL: "taken", LongURL: "https://example.com"}).Error)
+
+	body := `[{"long_url":"https://example.com/1","custom_url":"taken"},{"long_url":"https://example.com/2"},{"long_url":"https://example.com/3","custom_url":"free"}]`
+	req := httptest.NewRequest(http.MethodPost, "/v1/shorten/bulk", strings.NewReader(body))
+	w := httptest.NewRecorder()
+
+	ShortenBulk(w, req)
+
+	var resp BulkResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, 2, resp.Succeeded)
+	assert.Equal(t, codeCustomURLTaken, resp.Results[0].Code)
+	assert.Equal(t, http.StatusCreated, resp.Results[1].Status)
+	assert.NotEmpty(t, resp.Results[1].ShortURL)
+	assert.Equal(t, "free", resp.Results[2].ShortURL)
+}
+
+func TestShortenBulkInvalidatesCachedMisses(t *testing.T) {
+	conn := useSQLiteDB(t)
+	previous := Links
+	Links = store.NewCachedStore(store.NewSQLStore(conn), store.CacheOptions{Size: 10, TTL: time.Minute, NegativeTTL: time.Minute})
+	t.Cleanup(func() { Links = previous })
+
+	w := httptest.NewRecorder()
+	RedirectURL(w, httptest.NewRequest(http.MethodGet, "/fresh", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	body := `[{"long_url":"https://example.com/fresh","custom_url":"fresh"}]`
+	w = httptest.NewRecorder()
+	ShortenBulk(w, httptest.NewRequest(http.MethodPost, "/v1/shorten/bulk", strings.NewReader(body)))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	RedirectURL(w, httptest.NewRequest(http.MethodGet, "/fresh", nil))
+	assert.Equal(t, http.StatusFound, w.Code)
+	assert.Equal(t, "https://example.com/fresh", w.Header().Get("Location"))
+}
+
+func TestCreateEach(t *testing.T) {
+	conn := useSQLiteDB(t)
+	require.NoError(t, conn.Create(&[]models.URL{
+		{ShortURL: "race", LongURL: "https://example.com"},
+		{ShortURL: "gen123", LongURL: "https://example.com"},
+	}).Error)
+
+	// As if a concurrent request created "race" and "gen123" after they were checked
+	urls := []*models.URL{
+		{ShortURL: "race", LongURL: "https://example.com/1"},
+		{ShortURL: "free", LongURL: "https://example.com/2", Variants: []models.URLVariant{{LongURL: "https://example.com/2", Weight: 1}}},
+		{ShortURL: "gen123", LongURL: "https://example.com/3"},
+		nil,
+	}
+	results := make([]BulkResult, len(urls))
+	results[3] = errorResult(13, newShortenError(http.StatusBadRequest, codeInvalidURL, "Invalid URL format"))
+	createEach(context.Background(), results, urls, []bool{false, false, true, false}, 10)
+
+	// Only the custom URL taken in the meantime is a conflict; the generated one is replaced
+	assert.Equal(t, codeCustomURLTaken, results[0].Code)
+	assert.Equal(t, 10, results[0].Index)
+	assert.Empty(t, results[1].Code)
+	assert.NotZero(t, urls[1].ID)
+	assert.Empty(t, results[2].Code)
+	assert.NotEqual(t, "gen123", urls[2].ShortURL)
+	assert.Equal(t, codeInvalidURL, results[3].Code)
+
+	var count int64
+	conn.Model(&models.URL{}).Count(&count)
+	assert.EqualValues(t, 4, count)
+}
+
+func TestScanBatch(t *testing.T) {
+	var inFlight, peak int32
+	scanner := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+
+		var req map[string]string
+		json.NewDecoder(r.Body).Decode(&req)
+		json.NewEncoder(w).Encode(utils.URLScanResponse{Safe: !strings.Contains(req["url"], "malware")})
+	}))
+	defer scanner.Close()
+
+	urls := make([]*models.URL, 3*scanWorkers)
+	for i := range urls {
+		urls[i] = &models.URL{LongURL: fmt.Sprintf("https://example.com/%d", i)}
+	}
+	urls[1] = nil
+	urls[2].LongURL = "https://malware.example.com"
+
+	cfg := config.ScannerConfig{Enabled: true, Endpoint: scanner.URL, APIKey: "key"}
+	errs := scanBatch(context.Background(), urls, cfg)
+	assert.Nil(t, errs[0])
+	assert.Nil(t, errs[1], "Items that already failed are not scanned")
+	if assert.NotNil(t, errs[2]) {
+		assert.Equal(t, codeUnsafeURL, errs[2].Code)
+	}
+	assert.Greater(t, peak, int32(1), "Items are scanned concurrently")
+	assert.LessOrEqual(t, peak, int32(scanWorkers))
+}
@@ -0,0 +1,46 @@
+package v1
+
+import (
+	"GoShort/internal/store"
+	"context"
+	"encoding/json"
+	"net/http"
+)
+
+// invalidateLink drops the cached copy of a link changed without going through Links
+func invalidateLink(ctx context.Context, domain, shortURL string) {
+	if cache, ok := Links.(store.Invalidator); ok {
+		cache.Invalidate(ctx, domain, shortURL)
+	}
+}
+
+// invalidateDomain drops the cached lookup of a custom domain after it was saved or deleted
+func invalidateDomain(ctx context.Context, host string) {
+	if cache, ok := Links.(store.DomainFinder); ok {
+		cache.InvalidateDomain(ctx, host)
+	}
+}
+
+// GetCacheStats returns the hit, miss and eviction counters of the link cache
+func GetCacheStats(w http.ResponseWriter, r *http.Request) {
+	cache, ok := Links.(interface{ Stats() store.CacheStats })
+	if !ok {
+		http.Error(w, "Cache is disabled", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(cache.Stats())
+}
+
+// PurgeCache drops every cached link, e.g. after links were changed by the import command
+func PurgeCache(w http.ResponseWriter, r *http.Request) {
+	cache, ok := Links.(interface{ Purge() })
+	if !ok {
+		http.Error(w, "Cache is disabled", http.StatusNotFound)
+		return
+	}
+
+	cache.Purge()
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,33 @@
+package v1
+
+import (
+	"GoShort/internal/store"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCacheEndpoints(t *testing.T) {
+	useMemoryStore(t)
+	w := httptest.NewRecorder()
+	GetCacheStats(w, httptest.NewRequest(http.MethodGet, "/v1/admin/cache", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code, "Stats need a cache")
+
+	Links = store.NewCachedStore(Links, store.CacheOptions{Size: 10, TTL: time.Minute, NegativeTTL: time.Minute})
+	RedirectURL(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/missing", nil))
+	RedirectURL(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/missing", nil))
+
+	w = httptest.NewRecorder()
+	GetCacheStats(w, httptest.NewRequest(http.MethodGet, "/v1/admin/cache", nil))
+	var stats store.CacheStats
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&stats))
+	assert.Equal(t, store.CacheStats{Hits: 1, Misses: 1, Entries: 1}, stats)
+
+	w = httptest.NewRecorder()
+	PurgeCache(w, httptest.NewRequest(http.MethodDelete, "/v1/admin/cache", nil))
+	assert.Equal(t, http.StatusNoContent, w.Code)
+}
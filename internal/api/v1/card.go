@@ -0,0 +1,60 @@
+package v1
+
+import (
+	"GoShort/internal/models"
+	"html/template"
+	"net/http"
+)
+
+// cardTemplate renders the OpenGraph and Twitter card metadata served to crawlers
+var cardTemplate = template.Must(template.New("card").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<meta property="og:type" content="website">
+<meta property="og:url" content="{{.Destination}}">
+{{if .Title}}<meta property="og:title" content="{{.Title}}">
+<meta name="twitter:title" content="{{.Title}}">
+{{end}}{{if .Description}}<meta name="description" content="{{.Description}}">
+<meta property="og:description" content="{{.Description}}">
+<meta name="twitter:description" content="{{.Description}}">
+{{end}}{{if .Image}}<meta property="og:image" content="{{.Image}}">
+<meta name="twitter:image" content="{{.Image}}">
+<meta name="twitter:card" content="summary_large_image">
+{{else}}<meta name="twitter:card" content="summary">
+{{end}}<meta http-equiv="refresh" content="0; url={{.Destination}}">
+</head>
+<body>
+<a href="{{.Destination}}">{{.Destination}}</a>
+</body>
+</html>
+`))
+
+// cardPage holds the data shown to crawlers
+type cardPage struct {
+	Title       string
+	Description string
+	Image       string
+	Destination string
+}
+
+// hasCard reports whether url carries custom social card metadata
+func hasCard(url *models.URL) bool {
+	return url.OGTitle != "" || url.OGDescription != "" || url.OGImage != ""
+}
+
+// renderCard writes the social card page for url pointing at destination
+func renderCard(w http.ResponseWriter, url *models.URL, destination string) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+
+	page := cardPage{
+		Title:       url.OGTitle,
+		Description: url.OGDescription,
+		Image:       url.OGImage,
+		Destination: destination,
+	}
+	if err := cardTemplate.Execute(w, page); err != nil {
+		http.Error(w, "Failed to render card", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,30 @@
+package v1
+
+import (
+	"GoShort/internal/models"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderCard(t *testing.T) {
+	url := &models.URL{
+		ShortURL:      "launch",
+		LongURL:       "https://example.com/launch",
+		OGTitle:       "Launch \"day\"",
+		OGDescription: "Everything you need to know",
+		OGImage:       "https://example.com/card.png",
+	}
+	assert.True(t, hasCard(url))
+	assert.False(t, hasCard(&models.URL{LongURL: "https://example.com"}))
+
+	w := httptest.NewRecorder()
+	renderCard(w, url, url.LongURL)
+
+	body := w.Body.String()
+	assert.Contains(t, body, `<meta property="og:title" content="Launch &#34;day&#34;">`)
+	assert.Contains(t, body, `<meta property="og:description" content="Everything you need to know">`)
+	assert.Contains(t, body, `<meta property="og:image" content="https://example.com/card.png">`)
+	assert.Contains(t, body, `<meta name="twitter:card" content="summary_large_image">`)
+}
@@ -1,9 +1,13 @@
 package v1
 
 import (
+	"GoShort/internal/models"
+	"GoShort/pkg/config"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"os"
+	"sync/atomic"
 )
 
 // Config represents the frontend configuration
@@ -21,30 +25,120 @@ type Config struct {
 	FooterLink     string `json:"footerLink,omitempty"`
 }
 
-// GetConfig returns the frontend configuration
-func GetConfig(w http.ResponseWriter, r *http.Request) {
-	config := Config{}
-
-	// Load config from environment variables
-	envVars := map[string]*string{
-		"BRAND_TITLE":          &config.Title,
-		"BRAND_DESCRIPTION":    &config.Description,
-		"BRAND_KEYWORDS":       &config.Keywords,
-		"BRAND_AUTHOR":         &config.Author,
-		"BRAND_THEME_COLOR":    &config.ThemeColor,
-		"BRAND_LOGO_TEXT":      &config.LogoText,
-		"BRAND_PRIMARY_COLOR":  &config.PrimaryColor,
-		"BRAND_SECONDARY_COLOR": &config.SecondaryColor,
-		"BRAND_HEADER_TITLE":   &config.HeaderTitle,
-		"BRAND_FOOTER_TEXT":    &config.FooterText,
-		"BRAND_FOOTER_LINK":    &config.FooterLink,
-	}
-
-	for envVar, field := range envVars {
-		if value := os.Getenv(envVar); value != "" {
-			*field = value
+// loadConfig returns the global branding of cfg
+func loadConfig(cfg *config.Config) Config {
+	branding := cfg.Branding
+	return Config{
+		Title:          branding.Title,
+		Description:    branding.Description,
+		Keywords:       branding.Keywords,
+		Author:         branding.Author,
+		ThemeColor:     branding.ThemeColor,
+		LogoText:       branding.LogoText,
+		PrimaryColor:   branding.PrimaryColor,
+		SecondaryColor: branding.SecondaryColor,
+		HeaderTitle:    branding.HeaderTitle,
+		FooterText:     branding.FooterText,
+		FooterLink:     branding.FooterLink,
+	}
+}
+
+// loadedBranding is the per-domain branding read from a branding file
+type loadedBranding struct {
+	path    string
+	domains map[string]Config
+}
+
+// brandingFile holds the branding file loaded by LoadBrandingFile
+var brandingFile atomic.Pointer[loadedBranding]
+
+// LoadBrandingFile reads the branding file at path, a JSON object mapping hostnames to
+// branding, and serves it from then on. An empty path unloads it. The previously loaded file
+// is kept when path cannot be read or parsed.
+func LoadBrandingFile(path string) error {
+	if path == "" {
+		brandingFile.Store(nil)
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read branding file: %w", err)
+	}
+	var domains map[string]Config
+	if err := json.Unmarshal(data, &domains); err != nil {
+		return fmt.Errorf("failed to parse branding file: %w", err)
+	}
+
+	// Match hosts however they are written in the file
+	loaded := &loadedBranding{path: path, domains: make(map[string]Config, len(domains))}
+	for h, branding := range domains {
+		loaded.domains[normalizeHost(h)] = branding
+	}
+	brandingFile.Store(loaded)
+	return nil
+}
+
+// fileBranding returns the branding configured for host in the branding file at path, if it
+// is the one loaded
+func fileBranding(path, host string) (Config, bool) {
+	loaded := brandingFile.Load()
+	if loaded == nil || loaded.path != path || host == "" {
+		return Config{}, false
+	}
+	branding, ok := loaded.domains[normalizeHost(host)]
+	return branding, ok
+}
+
+// brandingFor returns the branding for a request sent to domain. Fields are taken from the
+// custom domain when set there, then from the branding file, then from the global branding.
+func brandingFor(r *http.Request, domain *models.Domain) Config {
+	cfg := config.FromContext(r.Context())
+	host := r.Host
+	if domain != nil {
+		host = domain.Host
+	}
+
+	config := loadConfig(cfg)
+	if overrides, ok := fileBranding(cfg.Branding.File, host); ok {
+		config = mergeBranding(config, overrides)
+	}
+	if domain != nil {
+		config = mergeBranding(config, brandingConfig(domain.Branding))
+	}
+	return config
+}
+
+// mergeBranding overlays the non-empty fields of overrides onto config
+func mergeBranding(config, overrides Config) Config {
+	fields := []struct{ dst, src *string }{
+		{&config.Title, &overrides.Title},
+		{&config.Description, &overrides.Description},
+		{&config.Keywords, &overrides.Keywords},
+		{&config.Author, &overrides.Author},
+		{&config.ThemeColor, &overrides.ThemeColor},
+		{&config.LogoText, &overrides.LogoText},
+		{&config.PrimaryColor, &overrides.PrimaryColor},
+		{&config.SecondaryColor, &overrides.SecondaryColor},
+		{&config.HeaderTitle, &overrides.HeaderTitle},
+		{&config.FooterText, &overrides.FooterText},
+		{&config.FooterLink, &overrides.FooterLink},
+	}
+	for _, f := range fields {
+		if *f.src != "" {
+			*f.dst = *f.src
 		}
 	}
+	return config
+}
+
+// GetConfig returns the frontend configuration for the domain the request was sent to
+func GetConfig(w http.ResponseWriter, r *http.Request) {
+	domain, err := requestDomain(r)
+	if err != nil {
+		domainLookupFailed(w)
+		return
+	}
+	config := brandingFor(r, domain)
 
 	// Set response headers
 	w.Header().Set("Content-Type", "application/json")
@@ -1,15 +1,38 @@
 package v1
 
 import (
+	"GoShort/pkg/config"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
-	"os"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 )
 
+// loadEnvConfig makes the current environment the active configuration for the rest of the test
+func loadEnvConfig(t *testing.T) {
+	cfg := config.Default()
+	assert.NoError(t, cfg.ApplyEnv())
+	config.Set(cfg)
+	t.Cleanup(func() { config.Set(config.Default()) })
+}
+
+// brandingEnv lists the environment variables read by the branding and preview tests
+var brandingEnv = []string{
+	"BRAND_TITLE", "BRAND_DESCRIPTION", "BRAND_KEYWORDS", "BRAND_AUTHOR", "BRAND_THEME_COLOR",
+	"BRAND_LOGO_TEXT", "BRAND_PRIMARY_COLOR", "BRAND_SECONDARY_COLOR", "BRAND_HEADER_TITLE",
+	"BRAND_FOOTER_TEXT", "BRAND_FOOTER_LINK", "BRANDING_FILE", "PREVIEW_MODE",
+}
+
+// clearEnv blanks the branding variables for the duration of the test, since ApplyEnv
+// ignores empty variables
+func clearEnv(t *testing.T) {
+	for _, name := range brandingEnv {
+		t.Setenv(name, "")
+	}
+}
+
 func TestGetConfig(t *testing.T) {
 	// Test cases
 	tests := []struct {
@@ -58,12 +81,13 @@ func TestGetConfig(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			// Clear environment variables
-			os.Clearenv()
+			clearEnv(t)
 
 			// Set environment variables for this test
 			for k, v := range tt.envVars {
-				os.Setenv(k, v)
+				t.Setenv(k, v)
 			}
+			loadEnvConfig(t)
 
 			// Create request
 			req, err := http.NewRequest("GET", "/v1/config", nil)
@@ -129,13 +153,14 @@ func TestGetConfig(t *testing.T) {
 
 func TestGetConfigMultipleValues(t *testing.T) {
 	// Clear environment variables
-	os.Clearenv()
+	clearEnv(t)
 
 	// Set multiple environment variables
-	os.Setenv("BRAND_TITLE", "Test Title")
-	os.Setenv("BRAND_PRIMARY_COLOR", "#ff0000")
-	os.Setenv("BRAND_SECONDARY_COLOR", "#00ff00")
-	os.Setenv("BRAND_FOOTER_TEXT", "Custom Footer")
+	t.Setenv("BRAND_TITLE", "Test Title")
+	t.Setenv("BRAND_PRIMARY_COLOR", "#ff0000")
+	t.Setenv("BRAND_SECONDARY_COLOR", "#00ff00")
+	t.Setenv("BRAND_FOOTER_TEXT", "Custom Footer")
+	loadEnvConfig(t)
 
 	// Create request
 	req, err := http.NewRequest("GET", "/v1/config", nil)
@@ -0,0 +1,277 @@
+package v1
+
+import (
+	"GoShort/internal/db"
+	"GoShort/internal/models"
+	"GoShort/internal/store"
+	"GoShort/internal/utils"
+	"GoShort/pkg/config"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+)
+
+// hostPattern matches a DNS hostname
+var hostPattern = regexp.MustCompile(`^([a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?\.)*[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$`)
+
+// DomainRequest represents the request payload for creating or updating a domain
+type DomainRequest struct {
+	Host            string `json:"host"`
+	DefaultRedirect string `json:"default_redirect,omitempty"`
+	Branding        Config `json:"branding"`
+}
+
+// DomainResponse represents a configured domain
+type DomainResponse struct {
+	Host            string    `json:"host"`
+	DefaultRedirect string    `json:"default_redirect,omitempty"`
+	Branding        Config    `json:"branding"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// normalizeHost lower-cases a host and strips any port
+func normalizeHost(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.TrimSuffix(strings.ToLower(host), ".")
+}
+
+// findDomain returns the configured domain for host, or nil when host is not a custom domain.
+// Lookup failures are returned rather than treated as the default namespace, where links
+// would resolve or be created under the wrong domain.
+func findDomain(ctx context.Context, host string) (*models.Domain, error) {
+	host = normalizeHost(host)
+	if host == "" {
+		return nil, nil
+	}
+	if domains, ok := Links.(store.DomainFinder); ok {
+		return domains.FindDomain(ctx, host)
+	}
+	if db.DB == nil {
+		return nil, nil
+	}
+
+	var domain models.Domain
+	err := db.Read(ctx, func(conn *gorm.DB) error {
+		return conn.Where("host = ?", host).First(&domain).Error
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &domain, nil
+}
+
+// requestDomain returns the custom domain the request was sent to, or nil for the default namespace
+func requestDomain(r *http.Request) (*models.Domain, error) {
+	return findDomain(r.Context(), r.Host)
+}
+
+// domainLookupFailed answers a request whose domain could not be looked up
+func domainLookupFailed(w http.ResponseWriter) {
+	http.Error(w, "Failed to look up domain", http.StatusServiceUnavailable)
+}
+
+// apiDomain returns the domain addressed by an API request: the "domain" query parameter
+// when given, otherwise the domain the request was sent to. ok is false for unknown domains.
+func apiDomain(r *http.Request) (domain *models.Domain, ok bool, err error) {
+	if host := r.URL.Query().Get("domain"); host != "" {
+		domain, err = findDomain(r.Context(), host)
+		return domain, domain != nil, err
+	}
+	domain, err = requestDomain(r)
+	return domain, true, err
+}
+
+// domainHost returns the namespace of a domain, which is empty for the default namespace
+func domainHost(domain *models.Domain) string {
+	if domain == nil {
+		return ""
+	}
+	return domain.Host
+}
+
+// brandingConfig converts stored domain branding to its API representation
+func brandingConfig(b models.Branding) Config {
+	return Config{
+		Title:          b.Title,
+		Description:    b.Description,
+		Keywords:       b.Keywords,
+		Author:         b.Author,
+		ThemeColor:     b.ThemeColor,
+		LogoText:       b.LogoText,
+		PrimaryColor:   b.PrimaryColor,
+		SecondaryColor: b.SecondaryColor,
+		HeaderTitle:    b.HeaderTitle,
+		FooterText:     b.FooterText,
+		FooterLink:     b.FooterLink,
+	}
+}
+
+// modelBranding converts API branding to its stored representation
+func modelBranding(c Config) models.Branding {
+	return models.Branding{
+		Title:          c.Title,
+		Description:    c.Description,
+		Keywords:       c.Keywords,
+		Author:         c.Author,
+		ThemeColor:     c.ThemeColor,
+		LogoText:       c.LogoText,
+		PrimaryColor:   c.PrimaryColor,
+		SecondaryColor: c.SecondaryColor,
+		HeaderTitle:    c.HeaderTitle,
+		FooterText:     c.FooterText,
+		FooterLink:     c.FooterLink,
+	}
+}
+
+// newDomainResponse converts a stored domain to its API representation
+func newDomainResponse(domain *models.Domain) DomainResponse {
+	return DomainResponse{
+		Host:            domain.Host,
+		DefaultRedirect: domain.DefaultRedirect,
+		Branding:        brandingConfig(domain.Branding),
+		CreatedAt:       domain.CreatedAt,
+	}
+}
+
+// hostAllowed reports whether the domain policy accepts requests for host. Without a strict
+// policy every host is accepted; otherwise only the configured hosts and registered custom domains.
+func hostAllowed(ctx context.Context, policy config.DomainsConfig, host string) (bool, error) {
+	if !policy.Strict {
+		return true, nil
+	}
+	host = normalizeHost(host)
+	for _, h := range policy.Hosts {
+		if normalizeHost(h) == host {
+			return true, nil
+		}
+	}
+	domain, err := findDomain(ctx, host)
+	return domain != nil, err
+}
+
+// EnforceDomainPolicy rejects requests for hosts the domain policy does not accept
+func EnforceDomainPolicy(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		allowed, err := hostAllowed(r.Context(), config.FromContext(r.Context()).Domains, r.Host)
+		if err != nil {
+			domainLookupFailed(w)
+			return
+		}
+		if !allowed {
+			http.Error(w, "Unknown host", http.StatusMisdirectedRequest)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// CertificateHostPolicy allows ACME certificates only for the configured hosts and the
+// registered custom domains, whether or not the domain policy is strict, so that arbitrary
+// SNI names cannot exhaust the CA's rate limits
+func CertificateHostPolicy(ctx context.Context, host string) error {
+	policy := config.Current().Domains
+	policy.Strict = true
+	allowed, err := hostAllowed(ctx, policy, host)
+	if err != nil {
+		return fmt.Errorf("failed to look up host %q: %w", host, err)
+	}
+	if !allowed {
+		return fmt.Errorf("no certificate for unknown host %q", host)
+	}
+	return nil
+}
+
+// ListDomains returns every configured custom domain
+func ListDomains(w http.ResponseWriter, r *http.Request) {
+	var domains []models.Domain
+	if err := db.DB.WithContext(r.Context()).Order("host").Find(&domains).Error; err != nil {
+		http.Error(w, "Failed to load domains", http.StatusInternalServerError)
+		return
+	}
+
+	resp := make([]DomainResponse, 0, len(domains))
+	for i := range domains {
+		resp = append(resp, newDomainResponse(&domains[i]))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// SaveDomain creates a custom domain or updates the one with the same host
+func SaveDomain(w http.ResponseWriter, r *http.Request) {
+	var req DomainRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	host := normalizeHost(req.Host)
+	if !hostPattern.MatchString(host) {
+		http.Error(w, "Invalid host", http.StatusBadRequest)
+		return
+	}
+	if req.DefaultRedirect != "" && !utils.ValidateURL(req.DefaultRedirect) {
+		http.Error(w, "Invalid default redirect URL", http.StatusBadRequest)
+		return
+	}
+
+	domain := models.Domain{Host: host}
+	if err := db.DB.WithContext(r.Context()).Where("host = ?", host).FirstOrInit(&domain).Error; err != nil {
+		http.Error(w, "Failed to load domain", http.StatusInternalServerError)
+		return
+	}
+	domain.DefaultRedirect = req.DefaultRedirect
+	domain.Branding = modelBranding(req.Branding)
+	if err := db.DB.WithContext(r.Context()).Save(&domain).Error; err != nil {
+		http.Error(w, "Failed to save domain", http.StatusInternalServerError)
+		return
+	}
+	invalidateDomain(r.Context(), host)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(newDomainResponse(&domain))
+}
+
+// DeleteDomain removes a custom domain that no longer has short URLs. The check and the
+// delete are a single statement so that a link created meanwhile cannot be orphaned.
+func DeleteDomain(w http.ResponseWriter, r *http.Request) {
+	host := normalizeHost(mux.Vars(r)["host"])
+
+	conn := db.DB.WithContext(r.Context())
+	result := conn.Where("host = ? AND NOT EXISTS (SELECT 1 FROM urls WHERE urls.domain = ?)", host, host).Delete(&models.Domain{})
+	if result.Error != nil {
+		http.Error(w, "Failed to delete domain", http.StatusInternalServerError)
+		return
+	}
+	if result.RowsAffected == 0 {
+		// Either the domain does not exist or it still has links
+		var count int64
+		if err := conn.Model(&models.Domain{}).Where("host = ?", host).Count(&count).Error; err != nil {
+			http.Error(w, "Failed to check domain", http.StatusInternalServerError)
+			return
+		}
+		if count > 0 {
+			http.Error(w, "Domain still has short URLs", http.StatusConflict)
+			return
+		}
+		http.NotFound(w, r)
+		return
+	}
+	invalidateDomain(r.Context(), host)
+	w.WriteHeader(http.StatusNoContent)
+}
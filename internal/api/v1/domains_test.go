@@ -0,0 +1,177 @@
+package v1
+
+import (
+	"GoShort/internal/models"
+	"GoShort/pkg/config"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeHost(t *testing.T) {
+	tests := []struct {
+		host     string
+		expected string
+	}{
+		{"go.example.com", "go.example.com"},
+		{"Go.Example.COM", "go.example.com"},
+		{"go.example.com:8080", "go.example.com"},
+		{"go.example.com.", "go.example.com"},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.host, func(t *testing.T) {
+			assert.Equal(t, tt.expected, normalizeHost(tt.host))
+		})
+	}
+}
+
+func TestHostPattern(t *testing.T) {
+	assert.True(t, hostPattern.MatchString("go.example.com"))
+	assert.True(t, hostPattern.MatchString("localhost"))
+	assert.False(t, hostPattern.MatchString("-bad.example.com"))
+	assert.False(t, hostPattern.MatchString("bad_host.example.com"))
+	assert.False(t, hostPattern.MatchString("example.com/path"))
+}
+
+func TestBrandingForDomain(t *testing.T) {
+	clearEnv(t)
+	t.Setenv("BRAND_TITLE", "GoShort")
+	t.Setenv("BRAND_PRIMARY_COLOR", "#3b82f6")
+	loadEnvConfig(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/config", nil)
+	assert.Equal(t, "GoShort", brandingFor(req, nil).Title)
+
+	domain := &models.Domain{Host: "go.example.com", Branding: models.Branding{Title: "Example Links"}}
+	branding := brandingFor(req, domain)
+	assert.Equal(t, "Example Links", branding.Title)
+	assert.Equal(t, "#3b82f6", branding.PrimaryColor)
+}
+
+func TestSaveDomainRejectsInvalidHost(t *testing.T) {
+	body := `{"host":"not a host"}`
+	req := httptest.NewRequest(http.MethodPost, "/v1/admin/domains", strings.NewReader(body))
+	w := httptest.NewRecorder()
+
+	SaveDomain(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestGetConfigUsesBrandingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "branding.json")
+	assert.NoError(t, os.WriteFile(path, []byte(`{"Go.Example.com":{"title":"Example Links","footerText":"Example footer"}}`), 0o644))
+
+	clearEnv(t)
+	t.Setenv("BRAND_TITLE", "GoShort")
+	t.Setenv("BRAND_PRIMARY_COLOR", "#3b82f6")
+	t.Setenv("BRANDING_FILE", path)
+	loadEnvConfig(t)
+	require.NoError(t, LoadBrandingFile(path))
+	t.Cleanup(func() { LoadBrandingFile("") })
+
+	tests := []struct {
+		host           string
+		expectedTitle  string
+		expectedFooter string
+	}{
+		{"go.example.com:8080", "Example Links", "Example footer"},
+		{"other.example.com", "GoShort", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.host, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/v1/config", nil)
+			req.Host = tt.host
+			w := httptest.NewRecorder()
+
+			GetConfig(w, req)
+
+			var result Config
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+			assert.Equal(t, tt.expectedTitle, result.Title)
+			assert.Equal(t, tt.expectedFooter, result.FooterText)
+			assert.Equal(t, "#3b82f6", result.PrimaryColor)
+		})
+	}
+}
+
+func TestEnforceDomainPolicy(t *testing.T) {
+	handler := EnforceDomainPolicy(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	serve := func(cfg *config.Config, host string) int {
+		req := httptest.NewRequest(http.MethodGet, "/promo", nil)
+		req.Host = host
+		req = req.WithContext(config.WithContext(req.Context(), cfg))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	cfg := config.Default()
+	assert.Equal(t, http.StatusOK, serve(cfg, "anything.example.com"))
+
+	cfg.Domains = config.DomainsConfig{Strict: true, Hosts: []string{"short.example.com"}}
+	assert.Equal(t, http.StatusOK, serve(cfg, "Short.Example.com:443"))
+	assert.Equal(t, http.StatusMisdirectedRequest, serve(cfg, "anything.example.com"))
+}
+
+func TestCertificateHostPolicy(t *testing.T) {
+	cfg := config.Default()
+	cfg.Domains.Hosts = []string{"short.example.com"}
+	config.Set(cfg)
+	t.Cleanup(func() { config.Set(config.Default()) })
+
+	assert.NoError(t, CertificateHostPolicy(context.Background(), "short.example.com"))
+	assert.Error(t, CertificateHostPolicy(context.Background(), "anything.example.com"), "Certificates are only issued for known hosts, even when the policy is not strict")
+}
+
+func TestDomainLookupFailure(t *testing.T) {
+	conn := useSQLiteDB(t)
+	useMemoryStore(t)
+	sqlDB, _ := conn.DB()
+	sqlDB.Close()
+
+	// A failed lookup must not fall back to the default namespace
+	req := httptest.NewRequest(http.MethodGet, "/promo", nil)
+	req.Host = "brand.example"
+	w := httptest.NewRecorder()
+	RedirectURL(w, req)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+	w = httptest.NewRecorder()
+	ShortenURL(w, httptest.NewRequest(http.MethodPost, "/v1/shorten", strings.NewReader(`{"long_url":"https://example.com"}`)))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+}
+
+func TestDeleteDomain(t *testing.T) {
+	conn := useSQLiteDB(t)
+	assert.NoError(t, conn.Create(&[]models.Domain{{Host: "used.example"}, {Host: "empty.example"}}).Error)
+	assert.NoError(t, conn.Create(&models.URL{Domain: "used.example", ShortURL: "promo", LongURL: "https://example.com"}).Error)
+
+	router := mux.NewRouter()
+	router.HandleFunc("/domains/{host}", DeleteDomain)
+	deleteDomain := func(host string) int {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/domains/"+host, nil))
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusConflict, deleteDomain("used.example"))
+	assert.Equal(t, http.StatusNoContent, deleteDomain("empty.example"))
+	assert.Equal(t, http.StatusNotFound, deleteDomain("empty.example"))
+
+	var count int64
+	conn.Model(&models.Domain{}).Count(&count)
+	assert.EqualValues(t, 1, count)
+}
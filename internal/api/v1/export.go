@@ -0,0 +1,89 @@
+package v1
+
+import (
+	"GoShort/internal/db"
+	"GoShort/internal/exporter"
+	"GoShort/pkg/logger"
+	"io"
+	"net/http"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// parseExportFilter reads the format and time range of an export request
+func parseExportFilter(r *http.Request) (string, exporter.Filter, bool) {
+	query := r.URL.Query()
+
+	format := query.Get("format")
+	if format == "" {
+		format = exporter.FormatJSON
+	}
+	if !exporter.ValidFormat(format) {
+		return "", exporter.Filter{}, false
+	}
+
+	var filter exporter.Filter
+	for param, field := range map[string]*time.Time{"since": &filter.Since, "until": &filter.Until} {
+		if value := query.Get(param); value != "" {
+			parsed, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				return "", exporter.Filter{}, false
+			}
+			*field = parsed
+		}
+	}
+	return format, filter, true
+}
+
+// serveExport streams an export as a file download
+func serveExport(w http.ResponseWriter, r *http.Request, name string, export func(*gorm.DB, io.Writer, string, exporter.Filter) error) {
+	format, filter, ok := parseExportFilter(r)
+	if !ok {
+		http.Error(w, "Invalid export parameters", http.StatusBadRequest)
+		return
+	}
+
+	filename := "goshort-" + name + "-" + time.Now().UTC().Format("20060102-150405") + "." + format
+	w.Header().Set("Content-Type", exporter.ContentType(format))
+	w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+	w.Header().Set("Cache-Control", "no-store")
+
+	// Large exports take longer than the server's write timeout allows
+	http.NewResponseController(w).SetWriteDeadline(time.Time{})
+
+	// An export a replica fails before writing anything is run again on the primary. Headers
+	// are already sent once streaming starts, so later failures can only be logged.
+	reader := db.Reader()
+	out := &countingWriter{w: w}
+	err := export(reader, out, format, filter)
+	if err != nil && reader != db.DB && out.n == 0 && r.Context().Err() == nil {
+		db.ReplicaFailed(reader, err)
+		err = export(db.DB, w, format, filter)
+	}
+	if err != nil {
+		logger.ErrorContext(r.Context(), "Failed to export", logger.Fields{"export": name, "error": err})
+	}
+}
+
+// countingWriter counts the bytes written through it
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// ExportLinks streams every short URL as CSV, JSON or NDJSON
+func ExportLinks(w http.ResponseWriter, r *http.Request) {
+	serveExport(w, r, "links", exporter.ExportLinks)
+}
+
+// ExportClicks streams every recorded click event as CSV, JSON or NDJSON
+func ExportClicks(w http.ResponseWriter, r *http.Request) {
+	serveExport(w, r, "clicks", exporter.ExportClicks)
+}
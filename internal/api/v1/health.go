@@ -0,0 +1,84 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// readinessTimeout bounds how long a readiness check may take
+const readinessTimeout = 2 * time.Second
+
+// ReadinessCheck checks a dependency needed to serve requests
+type ReadinessCheck struct {
+	Check    func(ctx context.Context) error
+	Optional bool // A failing optional check degrades the instance without making it unready
+}
+
+// ReadinessChecks are run by Readyz, by name
+var ReadinessChecks = map[string]ReadinessCheck{}
+
+// CheckResult is the outcome of one readiness check
+type CheckResult struct {
+	Status   string `json:"status"` // "ok" or "failed"
+	Error    string `json:"error,omitempty"`
+	Optional bool   `json:"optional,omitempty"`
+	Latency  string `json:"latency"`
+}
+
+// ReadinessResponse reports whether the instance can serve requests
+type ReadinessResponse struct {
+	Status string                 `json:"status"` // "ready", "degraded" or "unavailable"
+	Checks map[string]CheckResult `json:"checks"`
+}
+
+// Healthz reports that the process is alive. It checks no dependency, so that orchestrators
+// do not restart instances that are only waiting for the database.
+func Healthz(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+}
+
+// Readyz runs the readiness checks concurrently and answers 503 when a required one fails
+func Readyz(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), readinessTimeout)
+	defer cancel()
+
+	resp := ReadinessResponse{Status: "ready", Checks: make(map[string]CheckResult, len(ReadinessChecks))}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for name, check := range ReadinessChecks {
+		wg.Add(1)
+		go func(name string, check ReadinessCheck) {
+			defer wg.Done()
+			start := time.Now()
+			err := check.Check(ctx)
+			result := CheckResult{Status: "ok", Optional: check.Optional, Latency: time.Since(start).String()}
+			if err != nil {
+				result.Status, result.Error = "failed", err.Error()
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			resp.Checks[name] = result
+			switch {
+			case err == nil:
+			case !check.Optional:
+				resp.Status = "unavailable"
+			case resp.Status == "ready":
+				resp.Status = "degraded"
+			}
+		}(name, check)
+	}
+	wg.Wait()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if resp.Status == "unavailable" {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,50 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadyz(t *testing.T) {
+	previous := ReadinessChecks
+	t.Cleanup(func() { ReadinessChecks = previous })
+
+	ok := func(ctx context.Context) error { return nil }
+	failing := func(ctx context.Context) error { return errors.New("connection refused") }
+	readyz := func(checks map[string]ReadinessCheck) (int, ReadinessResponse) {
+		ReadinessChecks = checks
+		w := httptest.NewRecorder()
+		Readyz(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+		var resp ReadinessResponse
+		assert.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+		return w.Code, resp
+	}
+
+	code, resp := readyz(map[string]ReadinessCheck{"database": {Check: ok}, "cache": {Check: ok, Optional: true}})
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "ready", resp.Status)
+	assert.Equal(t, "ok", resp.Checks["database"].Status)
+
+	code, resp = readyz(map[string]ReadinessCheck{"database": {Check: ok}, "cache": {Check: failing, Optional: true}})
+	assert.Equal(t, http.StatusOK, code, "Expected a failing optional check to keep the instance ready")
+	assert.Equal(t, "degraded", resp.Status)
+	assert.Equal(t, "connection refused", resp.Checks["cache"].Error)
+
+	code, resp = readyz(map[string]ReadinessCheck{"database": {Check: failing}, "cache": {Check: failing, Optional: true}})
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "unavailable", resp.Status)
+	assert.Equal(t, "failed", resp.Checks["database"].Status)
+}
+
+func TestHealthz(t *testing.T) {
+	w := httptest.NewRecorder()
+	Healthz(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"status":"ok"}`, w.Body.String())
+}
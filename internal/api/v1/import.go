@@ -0,0 +1,63 @@
+package v1
+
+import (
+	"GoShort/internal/db"
+	"GoShort/internal/importer"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// maxImportBodyBytes limits the size of an uploaded export
+const maxImportBodyBytes = 50 << 20
+
+// ImportURLs imports links from another shortener's export sent as the request body.
+// The format, conflict strategy, target domain and dry run are selected with query parameters.
+func ImportURLs(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	strategy, err := importer.ParseStrategy(query.Get("strategy"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	dryRun, _ := strconv.ParseBool(query.Get("dry_run"))
+
+	var domain string
+	if host := query.Get("domain"); host != "" {
+		found, err := findDomain(r.Context(), host)
+		if err != nil {
+			domainLookupFailed(w)
+			return
+		}
+		if found == nil {
+			http.Error(w, "Unknown domain", http.StatusBadRequest)
+			return
+		}
+		domain = found.Host
+	}
+
+	// Uploading a large export can take longer than the server's read timeout allows
+	http.NewResponseController(w).SetReadDeadline(time.Time{})
+
+	records, err := importer.Parse(query.Get("format"), http.MaxBytesReader(w, r.Body, maxImportBodyBytes))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	report, err := importer.Import(db.DB, records, importer.Options{Strategy: strategy, DryRun: dryRun, Domain: domain})
+	if err != nil {
+		http.Error(w, "Failed to import URLs", http.StatusInternalServerError)
+		return
+	}
+
+	// Drop cached lookups of the links written, including cached misses of new ones
+	for _, shortURL := range report.Written() {
+		invalidateLink(r.Context(), domain, shortURL)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
@@ -0,0 +1,86 @@
+package v1
+
+import (
+	"GoShort/internal/db"
+	"GoShort/internal/models"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+)
+
+// URLResponse represents a stored short URL and its destination metadata
+type URLResponse struct {
+	Domain      string     `json:"domain,omitempty"`
+	ShortURL    string     `json:"short_url"`
+	LongURL     string     `json:"long_url"`
+	CreatedAt   time.Time  `json:"created_at"`
+	Expiry      *time.Time `json:"expiry,omitempty"`
+	ActivatesAt *time.Time `json:"activates_at,omitempty"`
+	Clicks      int        `json:"clicks"`
+	Title       string     `json:"title,omitempty"`
+	Description string     `json:"description,omitempty"`
+	FaviconURL  string     `json:"favicon_url,omitempty"`
+}
+
+// newURLResponse converts a stored URL to its API representation
+func newURLResponse(url *models.URL) URLResponse {
+	return URLResponse{
+		Domain:      url.Domain,
+		ShortURL:    url.ShortURL,
+		LongURL:     url.LongURL,
+		CreatedAt:   url.CreatedAt,
+		Expiry:      url.Expiry,
+		ActivatesAt: url.ActivatesAt,
+		Clicks:      url.Clicks,
+		Title:       url.Title,
+		Description: url.Description,
+		FaviconURL:  url.FaviconURL,
+	}
+}
+
+// findURL loads the short URL named in the request path from the namespace addressed by
+// the request, preloading the given associations. When the link cannot be returned it
+// answers the request itself and ok is false.
+func findURL(w http.ResponseWriter, r *http.Request, preloads ...string) (*models.URL, *models.Domain, bool) {
+	domain, ok, err := apiDomain(r)
+	if err != nil {
+		domainLookupFailed(w)
+		return nil, nil, false
+	}
+	if !ok {
+		http.NotFound(w, r)
+		return nil, nil, false
+	}
+
+	var url models.URL
+	err = db.Read(r.Context(), func(query *gorm.DB) error {
+		for _, association := range preloads {
+			query = query.Preload(association)
+		}
+		return query.Where("domain = ? AND short_url = ?", domainHost(domain), mux.Vars(r)["shortURL"]).First(&url).Error
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		http.NotFound(w, r)
+		return nil, nil, false
+	}
+	if err != nil {
+		http.Error(w, "Failed to load URL", http.StatusInternalServerError)
+		return nil, nil, false
+	}
+	return &url, domain, true
+}
+
+// GetURL returns a short URL together with its destination metadata
+func GetURL(w http.ResponseWriter, r *http.Request) {
+	url, _, ok := findURL(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(newURLResponse(url))
+}
@@ -0,0 +1,86 @@
+package v1
+
+import (
+	"GoShort/internal/models"
+	"GoShort/pkg/config"
+	"html/template"
+	"net/http"
+	"time"
+)
+
+// previewSuffix appended to a short URL shows its preview page instead of redirecting
+const previewSuffix = "+"
+
+// previewTemplate renders the interstitial page shown before leaving GoShort
+var previewTemplate = template.Must(template.New("preview").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="robots" content="noindex">
+<title>{{.ShortURL}} - {{.BrandTitle}}</title>
+<style>
+body{font-family:system-ui,sans-serif;background:#f3f4f6;color:#111827;margin:0;display:flex;min-height:100vh;align-items:center;justify-content:center}
+main{background:#fff;border-radius:.5rem;box-shadow:0 1px 3px rgba(0,0,0,.1);padding:2rem;max-width:36rem;width:100%}
+h1{font-size:1.25rem;margin-top:0}
+dt{font-weight:600;margin-top:.75rem}
+dd{margin:0;word-break:break-all}
+a.button{display:inline-block;margin-top:1.5rem;padding:.5rem 1rem;border-radius:.375rem;background:{{.PrimaryColor}};color:#fff;text-decoration:none}
+</style>
+</head>
+<body>
+<main>
+<h1>You are about to leave for another site</h1>
+<dl>
+<dt>Destination</dt>
+<dd><a href="{{.Destination}}" rel="noopener noreferrer nofollow">{{.Destination}}</a></dd>
+<dt>Created</dt>
+<dd>{{.CreatedAt.Format "January 2, 2006"}}</dd>
+<dt>Clicks</dt>
+<dd>{{.Clicks}}</dd>
+</dl>
+<a class="button" href="{{.Destination}}" rel="noopener noreferrer nofollow">Continue</a>
+</main>
+</body>
+</html>
+`))
+
+// previewPage holds the data shown on the preview page
+type previewPage struct {
+	ShortURL     string
+	Destination  string
+	CreatedAt    time.Time
+	Clicks       int
+	BrandTitle   string
+	PrimaryColor string
+}
+
+// previewModeEnabled reports whether every short URL should show its preview page
+func previewModeEnabled(r *http.Request) bool {
+	return config.FromContext(r.Context()).Features.PreviewMode
+}
+
+// renderPreview writes the preview page for url pointing at destination with the given branding
+func renderPreview(w http.ResponseWriter, url *models.URL, destination string, branding Config) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
+
+	page := previewPage{
+		ShortURL:    url.ShortURL,
+		Destination: destination,
+		CreatedAt:   url.CreatedAt,
+		Clicks:      url.Clicks,
+	}
+
+	page.BrandTitle = branding.Title
+	if page.BrandTitle == "" {
+		page.BrandTitle = "Link preview"
+	}
+	page.PrimaryColor = branding.PrimaryColor
+	if page.PrimaryColor == "" {
+		page.PrimaryColor = "#3b82f6"
+	}
+	if err := previewTemplate.Execute(w, page); err != nil {
+		http.Error(w, "Failed to render preview", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,43 @@
+package v1
+
+import (
+	"GoShort/internal/models"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderPreview(t *testing.T) {
+	url := &models.URL{
+		ShortURL:  "promo",
+		LongURL:   "https://example.com/?a=1&b=<2>",
+		CreatedAt: time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC),
+		Clicks:    42,
+	}
+
+	w := httptest.NewRecorder()
+	renderPreview(w, url, url.LongURL, Config{})
+
+	res := w.Result()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "text/html; charset=utf-8", res.Header.Get("Content-Type"))
+
+	body := w.Body.String()
+	assert.Contains(t, body, "https://example.com/?a=1&amp;b=%3c2%3e", "Expected destination to be escaped")
+	assert.Contains(t, body, "January 2, 2025")
+	assert.Contains(t, body, "42")
+}
+
+func TestPreviewModeEnabled(t *testing.T) {
+	clearEnv(t)
+	req := httptest.NewRequest(http.MethodGet, "/promo", nil)
+	loadEnvConfig(t)
+	assert.False(t, previewModeEnabled(req))
+
+	t.Setenv("PREVIEW_MODE", "true")
+	loadEnvConfig(t)
+	assert.True(t, previewModeEnabled(req))
+}
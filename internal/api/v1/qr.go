@@ -0,0 +1,189 @@
+package v1
+
+import (
+	"GoShort/internal/middleware"
+	"GoShort/internal/models"
+	"GoShort/internal/utils"
+	"GoShort/pkg/config"
+	"fmt"
+	"image/color"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Limits and defaults for generated QR codes
+const (
+	defaultQRSize   = 256
+	minQRSize       = 64
+	maxQRSize       = 2048
+	defaultQRMargin = 4
+	maxQRMargin     = 16
+	defaultQRLevel  = "M"
+	qrCacheSize     = 512
+)
+
+// qrCache keeps rendered QR codes keyed by their content and rendering parameters
+type qrCache struct {
+	mu      sync.Mutex
+	entries map[string][]byte
+	order   []string
+}
+
+// get returns a cached QR code
+func (c *qrCache) get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	data, ok := c.entries[key]
+	return data, ok
+}
+
+// put stores a QR code, evicting the oldest entry when the cache is full
+func (c *qrCache) put(key string, data []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, exists := c.entries[key]; exists {
+		return
+	}
+	if len(c.order) >= qrCacheSize {
+		delete(c.entries, c.order[0])
+		c.order = c.order[1:]
+	}
+	c.entries[key] = data
+	c.order = append(c.order, key)
+}
+
+var qrCodes = &qrCache{entries: make(map[string][]byte)}
+
+// shortLinkURL builds the public URL of a short link, on its custom domain when it has one
+// and else on domains.base_url. Without a base URL the request's host is used, which shared
+// caches may only keep when domains.strict has checked it, as reported by shared.
+func shortLinkURL(r *http.Request, link *models.URL) (content string, shared bool) {
+	cfg := config.FromContext(r.Context())
+	scheme, host := requestScheme(r, cfg.RateLimit.Proxies()), r.Host
+	shared = cfg.Domains.Strict
+	if base, err := url.Parse(cfg.Domains.BaseURL); err == nil && base.Host != "" {
+		scheme, host, shared = base.Scheme, base.Host, true
+	}
+	if link.Domain != "" {
+		host, shared = link.Domain, true
+	}
+	return scheme + "://" + host + "/" + link.ShortURL, shared
+}
+
+// requestScheme returns the scheme a request was made with, believing X-Forwarded-Proto only
+// from trusted proxies
+func requestScheme(r *http.Request, trusted []netip.Prefix) string {
+	if middleware.FromTrustedProxy(r, trusted) {
+		if proto := r.Header.Get("X-Forwarded-Proto"); proto == "http" || proto == "https" {
+			return proto
+		}
+	}
+	if r.TLS != nil {
+		return "https"
+	}
+	return "http"
+}
+
+// parseQRColor parses a colour query parameter, falling back to def when empty
+func parseQRColor(value string, def color.Color) (color.Color, error) {
+	if value == "" {
+		return def, nil
+	}
+	return utils.ParseHexColor(value)
+}
+
+// GetQRCode returns a PNG or SVG QR code pointing at a short URL
+func GetQRCode(w http.ResponseWriter, r *http.Request) {
+	url, domain, ok := findURL(w, r)
+	if !ok {
+		return
+	}
+
+	query := r.URL.Query()
+
+	format := strings.ToLower(query.Get("format"))
+	if format == "" {
+		format = "png"
+	}
+	if format != "png" && format != "svg" {
+		http.Error(w, "Invalid format", http.StatusBadRequest)
+		return
+	}
+
+	opts := utils.QROptions{Size: defaultQRSize, Margin: defaultQRMargin, Level: defaultQRLevel}
+	if v := query.Get("size"); v != "" {
+		size, err := strconv.Atoi(v)
+		if err != nil || size < minQRSize || size > maxQRSize {
+			http.Error(w, "Invalid size", http.StatusBadRequest)
+			return
+		}
+		opts.Size = size
+	}
+	if v := query.Get("margin"); v != "" {
+		margin, err := strconv.Atoi(v)
+		if err != nil || margin < 0 || margin > maxQRMargin {
+			http.Error(w, "Invalid margin", http.StatusBadRequest)
+			return
+		}
+		opts.Margin = margin
+	}
+	if v := strings.ToUpper(query.Get("level")); v != "" {
+		if !utils.ValidQRLevel(v) {
+			http.Error(w, "Invalid error correction level", http.StatusBadRequest)
+			return
+		}
+		opts.Level = v
+	}
+
+	// Default to the brand colour so codes match the rest of the instance. Brand colours are
+	// CSS, so black is used for those that are not #hex, such as "blue".
+	var defaultFG color.Color = color.Black
+	if brand, err := utils.ParseHexColor(brandingFor(r, domain).PrimaryColor); err == nil {
+		defaultFG = brand
+	}
+	var err error
+	if opts.Foreground, err = parseQRColor(query.Get("fg"), defaultFG); err != nil {
+		http.Error(w, "Invalid foreground colour", http.StatusBadRequest)
+		return
+	}
+	if opts.Background, err = parseQRColor(query.Get("bg"), color.White); err != nil {
+		http.Error(w, "Invalid background colour", http.StatusBadRequest)
+		return
+	}
+
+	content, shared := shortLinkURL(r, url)
+	key := fmt.Sprintf("%s|%s|%d|%d|%s|%s|%s", content, format, opts.Size, opts.Margin, opts.Level,
+		utils.HexColor(opts.Foreground), utils.HexColor(opts.Background))
+
+	data, ok := qrCodes.get(key)
+	if !ok {
+		if format == "svg" {
+			data, err = utils.QRCodeSVG(content, opts)
+		} else {
+			data, err = utils.QRCodePNG(content, opts)
+		}
+		if err != nil {
+			http.Error(w, "Failed to generate QR code", http.StatusInternalServerError)
+			return
+		}
+		qrCodes.put(key, data)
+	}
+
+	if format == "svg" {
+		w.Header().Set("Content-Type", "image/svg+xml")
+	} else {
+		w.Header().Set("Content-Type", "image/png")
+	}
+	if shared {
+		w.Header().Set("Cache-Control", "public, max-age=86400")
+	} else {
+		w.Header().Set("Cache-Control", "private, max-age=86400")
+	}
+	w.Write(data)
+}
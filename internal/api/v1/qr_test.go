@@ -0,0 +1,142 @@
+package v1
+
+import (
+	"GoShort/internal/db"
+	"GoShort/internal/models"
+	"GoShort/migrations"
+	"GoShort/pkg/config"
+	"bytes"
+	"crypto/tls"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// useSQLiteDB makes the handlers use a fresh, migrated SQLite database for the test
+func useSQLiteDB(t *testing.T) *gorm.DB {
+	conn, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "goshort.db")), &gorm.Config{
+		TranslateError: true,
+		Logger:         logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	_, err = migrations.Up(conn)
+	require.NoError(t, err)
+
+	previous := db.DB
+	db.DB = conn
+	t.Cleanup(func() {
+		db.DB = previous
+		sqlDB, _ := conn.DB()
+		sqlDB.Close()
+	})
+	return conn
+}
+
+func TestGetQRCode(t *testing.T) {
+	conn := useSQLiteDB(t)
+	require.NoError(t, conn.Create(&models.URL{ShortURL: "qrtest", LongURL: "https://example.com"}).Error)
+
+	router := mux.NewRouter()
+	router.HandleFunc("/v1/urls/{shortURL}/qr", GetQRCode)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/urls/qrtest/qr?size=128", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "image/png", w.Header().Get("Content-Type"))
+	assert.Equal(t, "private, max-age=86400", w.Header().Get("Cache-Control"), "Codes for the request's host are not shared")
+	img, err := png.Decode(bytes.NewReader(w.Body.Bytes()))
+	assert.NoError(t, err)
+	assert.Equal(t, 128, img.Bounds().Dx())
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/urls/qrtest/qr?format=svg", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "image/svg+xml", w.Header().Get("Content-Type"))
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/urls/qrtest/qr?level=X", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/urls/missing/qr", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestGetQRCodeBrandColour(t *testing.T) {
+	conn := useSQLiteDB(t)
+	require.NoError(t, conn.Create(&models.URL{ShortURL: "qrtest", LongURL: "https://example.com"}).Error)
+	router := mux.NewRouter()
+	router.HandleFunc("/v1/urls/{shortURL}/qr", GetQRCode)
+
+	clearEnv(t)
+	t.Setenv("BRAND_PRIMARY_COLOR", "#336699")
+	loadEnvConfig(t)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/urls/qrtest/qr?format=svg", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `<path fill="#336699"`)
+
+	// Brand colours that are valid CSS but not #hex fall back to black
+	t.Setenv("BRAND_PRIMARY_COLOR", "blue")
+	loadEnvConfig(t)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/urls/qrtest/qr?format=svg", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `<path fill="#000000"`)
+
+	// Only an invalid fg parameter is rejected
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/urls/qrtest/qr?fg=blue", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestShortLinkURL(t *testing.T) {
+	url := &models.URL{ShortURL: "abc123"}
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/urls/abc123/qr", nil)
+	req.Host = "go.example.com"
+	content, shared := shortLinkURL(req, url)
+	assert.Equal(t, "http://go.example.com/abc123", content)
+	assert.False(t, shared, "Unchecked hosts must not be cached by shared caches")
+
+	// X-Forwarded-Proto is only believed from trusted proxies
+	req.Header.Set("X-Forwarded-Proto", "https")
+	content, _ = shortLinkURL(req, url)
+	assert.Equal(t, "http://go.example.com/abc123", content)
+
+	cfg := config.Default()
+	cfg.RateLimit.TrustedProxies = []string{"192.0.2.0/24"}
+	req = req.WithContext(config.WithContext(req.Context(), cfg))
+	content, _ = shortLinkURL(req, url)
+	assert.Equal(t, "https://go.example.com/abc123", content)
+
+	req = httptest.NewRequest(http.MethodGet, "/v1/urls/abc123/qr", nil)
+	req.Host = "go.example.com"
+	req.TLS = &tls.ConnectionState{}
+	content, _ = shortLinkURL(req, url)
+	assert.Equal(t, "https://go.example.com/abc123", content)
+
+	// The base URL replaces the request's host
+	cfg = config.Default()
+	cfg.Domains.BaseURL = "https://short.example"
+	req = req.WithContext(config.WithContext(req.Context(), cfg))
+	req.Host = "spoofed.example"
+	content, shared = shortLinkURL(req, url)
+	assert.Equal(t, "https://short.example/abc123", content)
+	assert.True(t, shared)
+
+	// Links bound to a custom domain always point at it
+	url.Domain = "brand.example"
+	content, shared = shortLinkURL(req, url)
+	assert.Equal(t, "https://brand.example/abc123", content)
+	assert.True(t, shared)
+}
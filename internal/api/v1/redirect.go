@@ -1,28 +1,128 @@
 package v1
 
 import (
-	"GoShort/internal/db"
+	"GoShort/internal/analytics"
+	"GoShort/internal/metrics"
 	"GoShort/internal/models"
+	"GoShort/internal/store"
+	"GoShort/internal/utils"
+	"GoShort/pkg/logger"
+	"errors"
 	"net/http"
+	"strings"
 	"time"
 )
 
+// ClickRecorder counts redirects in the background, storing a click event for each when
+// analytics are on; when nil, clicks are counted through Links as they happen
+var ClickRecorder *analytics.Recorder
+
 // RedirectURL handles redirecting a short URL to its original URL
 func RedirectURL(w http.ResponseWriter, r *http.Request) {
 	shortURL := r.URL.Path[1:] // Extract the short URL from the path
 
-	var url models.URL
-	if err := db.DB.Where("short_url = ?", shortURL).First(&url).Error; err != nil {
+	// A trailing "+" asks for the preview page instead of the redirect
+	inspect := strings.HasSuffix(shortURL, previewSuffix)
+	shortURL = strings.TrimSuffix(shortURL, previewSuffix)
+
+	// Each custom domain has its own namespace of short URLs
+	domain, err := requestDomain(r)
+	if err != nil {
+		metrics.Redirects.WithLabelValues(metrics.RedirectError).Inc()
+		domainLookupFailed(w)
+		return
+	}
+
+	url, err := Links.FindLink(r.Context(), domainHost(domain), shortURL)
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		metrics.Redirects.WithLabelValues(metrics.RedirectError).Inc()
+		http.Error(w, "Failed to load URL", http.StatusInternalServerError)
+		return
+	}
+	if err != nil {
+		// Unknown short URLs on a custom domain go to its fallback page when one is set
+		if domain != nil && domain.DefaultRedirect != "" {
+			metrics.Redirects.WithLabelValues(metrics.RedirectFallback).Inc()
+			http.Redirect(w, r, domain.DefaultRedirect, http.StatusFound)
+			return
+		}
+		metrics.Redirects.WithLabelValues(metrics.RedirectNotFound).Inc()
 		http.NotFound(w, r)
 		return
 	}
 
+	now := time.Now()
+
 	// Check for expiration
-	if url.Expiry != nil && time.Now().After(*url.Expiry) {
+	if url.Expiry != nil && now.After(*url.Expiry) {
+		metrics.Redirects.WithLabelValues(metrics.RedirectExpired).Inc()
 		http.Error(w, "URL has expired", http.StatusGone)
 		return
 	}
 
+	// Links scheduled to go live later behave as if they did not exist yet
+	if url.ActivatesAt != nil && now.Before(*url.ActivatesAt) {
+		metrics.Redirects.WithLabelValues(metrics.RedirectInactive).Inc()
+		http.Error(w, "URL is not active yet", http.StatusNotFound)
+		return
+	}
+
+	// Inspecting a link shows where it currently points without counting a visit
+	if inspect {
+		metrics.Redirects.WithLabelValues(metrics.RedirectPreview).Inc()
+		renderPreview(w, url, currentDestination(url, now), brandingFor(r, domain))
+		return
+	}
+
+	// Crawlers unfurling the link get the custom social card instead of following the redirect
+	if hasCard(url) && utils.IsCrawler(r.UserAgent()) {
+		metrics.Redirects.WithLabelValues(metrics.RedirectCard).Inc()
+		renderCard(w, url, currentDestination(url, now))
+		return
+	}
+
+	// A scheduled destination takes precedence over split testing
+	target := url.LongURL
+	var variantID *uint
+	if schedule := activeSchedule(url.Schedules, now); schedule != nil {
+		target = schedule.LongURL
+	} else if variant := pickVariant(r, url.ShortURL, url.Variants); variant != nil {
+		// Rotate between weighted variants when the URL is split-tested
+		setVariantCookie(w, url.ShortURL, variant)
+		target = variant.LongURL
+		variantID = &variant.ID
+	}
+
+	if ClickRecorder != nil {
+		ClickRecorder.Record(models.ClickEvent{
+			URLID:     url.ID,
+			Domain:    url.Domain,
+			ShortURL:  url.ShortURL,
+			VariantID: variantID,
+			Referrer:  r.Referer(),
+			UserAgent: r.UserAgent(),
+		})
+	} else if err := Links.RecordClick(r.Context(), url.ID, variantID); err != nil {
+		logger.ErrorContext(r.Context(), "Failed to count click", logger.Fields{"short_code": url.ShortURL, "error": err})
+	}
+
+	// Show the interstitial page when the link or the instance asks for it
+	if url.Preview || previewModeEnabled(r) {
+		url.Clicks++
+		metrics.Redirects.WithLabelValues(metrics.RedirectPreview).Inc()
+		renderPreview(w, url, target, brandingFor(r, domain))
+		return
+	}
+
 	// Redirect to the original URL
-	http.Redirect(w, r, url.LongURL, http.StatusFound)
+	metrics.Redirects.WithLabelValues(metrics.RedirectFound).Inc()
+	http.Redirect(w, r, target, http.StatusFound)
+}
+
+// currentDestination returns where url points at now, ignoring split testing
+func currentDestination(url *models.URL, now time.Time) string {
+	if schedule := activeSchedule(url.Schedules, now); schedule != nil {
+		return schedule.LongURL
+	}
+	return url.LongURL
 }
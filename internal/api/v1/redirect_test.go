@@ -1,87 +1,93 @@
 package v1
 
 import (
+	"GoShort/internal/metrics"
 	"GoShort/internal/models"
+	"GoShort/internal/store"
+	"context"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 )
 
-func mockRedirectURL(shortToURLMap map[string]*models.URL) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		shortURL := r.URL.Path[1:] // Extract the short URL from the path
-
-		url, exists := shortToURLMap[shortURL]
-		if !exists {
-			http.NotFound(w, r)
-			return
-		}
-
-		// Check for expiration
-		if url.Expiry != nil && time.Now().After(*url.Expiry) {
-			http.Error(w, "URL has expired", http.StatusGone)
-			return
-		}
-
-		// Redirect to the original URL
-		http.Redirect(w, r, url.LongURL, http.StatusFound)
-	}
+// useMemoryStore makes the handlers use an empty in-memory link store for the test
+func useMemoryStore(t *testing.T) *store.MemoryStore {
+	links := store.NewMemoryStore()
+	previous := Links
+	Links = links
+	t.Cleanup(func() { Links = previous })
+	return links
 }
 
 func TestRedirectURL(t *testing.T) {
 	tests := []struct {
-		name          string
-		shortURL      string
-		mockData      map[string]*models.URL
-		expectedCode  int
+		name           string
+		shortURL       string
+		links          []*models.URL
+		expectedCode   int
 		expectedHeader string
+		expectedResult string
 	}{
 		{
 			name:     "Valid short URL",
 			shortURL: "short123",
-			mockData: map[string]*models.URL{
-				"short123": {
-					ShortURL: "short123",
-					LongURL:  "https://example.com",
-				},
+			links: []*models.URL{
+				{ShortURL: "short123", LongURL: "https://example.com"},
 			},
-			expectedCode:  http.StatusFound,
+			expectedCode:   http.StatusFound,
 			expectedHeader: "https://example.com",
+			expectedResult: metrics.RedirectFound,
 		},
 		{
-			name:         "Short URL not found",
-			shortURL:     "unknown123",
-			mockData:     map[string]*models.URL{},
-			expectedCode: http.StatusNotFound,
+			name:           "Short URL not found",
+			shortURL:       "unknown123",
+			expectedCode:   http.StatusNotFound,
+			expectedResult: metrics.RedirectNotFound,
 		},
 		{
 			name:     "Expired short URL",
 			shortURL: "expired123",
-			mockData: map[string]*models.URL{
-				"expired123": {
+			links: []*models.URL{
+				{
 					ShortURL: "expired123",
 					LongURL:  "https://example.com",
 					Expiry:   func() *time.Time { t := time.Now().Add(-time.Hour); return &t }(),
 				},
 			},
-			expectedCode: http.StatusGone,
+			expectedCode:   http.StatusGone,
+			expectedResult: metrics.RedirectExpired,
+		},
+		{
+			name:     "Short URL on another domain",
+			shortURL: "promo",
+			links: []*models.URL{
+				{ShortURL: "promo", LongURL: "https://example.com", Domain: "go.example.com"},
+			},
+			expectedCode:   http.StatusNotFound,
+			expectedResult: metrics.RedirectNotFound,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			// Create mock handler
-			handler := mockRedirectURL(tt.mockData)
+			links := useMemoryStore(t)
+			for _, url := range tt.links {
+				assert.NoError(t, links.CreateLink(context.Background(), url))
+			}
 
 			// Create HTTP request and recorder
 			req := httptest.NewRequest(http.MethodGet, "/"+tt.shortURL, nil)
 			w := httptest.NewRecorder()
 
-			// Call the mock handler
-			handler(w, req)
+			// Call the handler
+			counter := metrics.Redirects.WithLabelValues(tt.expectedResult)
+			before := testutil.ToFloat64(counter)
+			RedirectURL(w, req)
+			assert.Equal(t, before+1, testutil.ToFloat64(counter))
 
 			// Assert response code
 			res := w.Result()
@@ -95,3 +101,23 @@ func TestRedirectURL(t *testing.T) {
 		})
 	}
 }
+
+func TestRedirectURLCountsClicks(t *testing.T) {
+	links := useMemoryStore(t)
+	assert.NoError(t, links.CreateLink(context.Background(), &models.URL{
+		ShortURL: "split",
+		LongURL:  "https://example.com/a",
+		Variants: []models.URLVariant{{LongURL: "https://example.com/a", Weight: 1}},
+	}))
+
+	for i := 0; i < 3; i++ {
+		w := httptest.NewRecorder()
+		RedirectURL(w, httptest.NewRequest(http.MethodGet, "/split", nil))
+		assert.Equal(t, http.StatusFound, w.Code)
+	}
+
+	url, err := links.FindLink(context.Background(), "", "split")
+	assert.NoError(t, err)
+	assert.Equal(t, 3, url.Clicks)
+	assert.Equal(t, 3, url.Variants[0].Clicks)
+}
@@ -0,0 +1,57 @@
+package v1
+
+import (
+	"GoShort/internal/models"
+	"GoShort/internal/utils"
+	"errors"
+	"sort"
+	"time"
+)
+
+// maxSchedules limits how many scheduled destinations a single short URL can have
+const maxSchedules = 20
+
+// ScheduleRequest represents a destination that takes over from StartsAt onwards
+type ScheduleRequest struct {
+	LongURL  string `json:"long_url"`
+	StartsAt string `json:"starts_at"` // RFC3339 timestamp
+}
+
+// buildSchedules validates the requested schedule and converts it to models sorted by start time
+func buildSchedules(reqs []ScheduleRequest) ([]models.URLSchedule, error) {
+	if len(reqs) > maxSchedules {
+		return nil, errors.New("too many scheduled destinations")
+	}
+
+	schedules := make([]models.URLSchedule, 0, len(reqs))
+	for _, s := range reqs {
+		if !utils.ValidateURL(s.LongURL) {
+			return nil, errors.New("invalid scheduled URL format")
+		}
+		startsAt, err := time.Parse(time.RFC3339, s.StartsAt)
+		if err != nil {
+			return nil, errors.New("invalid scheduled start format")
+		}
+		schedules = append(schedules, models.URLSchedule{LongURL: s.LongURL, StartsAt: startsAt})
+	}
+
+	sort.Slice(schedules, func(i, j int) bool {
+		return schedules[i].StartsAt.Before(schedules[j].StartsAt)
+	})
+	return schedules, nil
+}
+
+// activeSchedule returns the scheduled destination in effect at now, if any.
+// The entry with the latest start time that is not in the future wins.
+func activeSchedule(schedules []models.URLSchedule, now time.Time) *models.URLSchedule {
+	var active *models.URLSchedule
+	for i := range schedules {
+		if schedules[i].StartsAt.After(now) {
+			continue
+		}
+		if active == nil || schedules[i].StartsAt.After(active.StartsAt) {
+			active = &schedules[i]
+		}
+	}
+	return active
+}
@@ -0,0 +1,44 @@
+package v1
+
+import (
+	"GoShort/internal/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestActiveSchedule(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	schedules := []models.URLSchedule{
+		{LongURL: "https://example.com/launch", StartsAt: now.Add(24 * time.Hour)},
+		{LongURL: "https://example.com/teaser", StartsAt: now.Add(-48 * time.Hour)},
+		{LongURL: "https://example.com/preorder", StartsAt: now.Add(-time.Hour)},
+	}
+
+	active := activeSchedule(schedules, now)
+	assert.NotNil(t, active)
+	assert.Equal(t, "https://example.com/preorder", active.LongURL, "Expected the latest started entry to win")
+
+	active = activeSchedule(schedules, now.Add(25*time.Hour))
+	assert.NotNil(t, active)
+	assert.Equal(t, "https://example.com/launch", active.LongURL)
+
+	assert.Nil(t, activeSchedule(schedules, now.Add(-72*time.Hour)), "Expected no entry before the first start")
+}
+
+func TestBuildSchedules(t *testing.T) {
+	schedules, err := buildSchedules([]ScheduleRequest{
+		{LongURL: "https://example.com/launch", StartsAt: "2025-02-01T00:00:00Z"},
+		{LongURL: "https://example.com/teaser", StartsAt: "2025-01-01T00:00:00Z"},
+	})
+	assert.NoError(t, err)
+	assert.Len(t, schedules, 2)
+	assert.Equal(t, "https://example.com/teaser", schedules[0].LongURL, "Expected schedule to be sorted by start")
+
+	_, err = buildSchedules([]ScheduleRequest{{LongURL: "https://example.com", StartsAt: "not-a-date"}})
+	assert.Error(t, err, "Expected invalid start to return an error")
+
+	_, err = buildSchedules([]ScheduleRequest{{LongURL: "invalid-url", StartsAt: "2025-01-01T00:00:00Z"}})
+	assert.Error(t, err, "Expected invalid URL to return an error")
+}
@@ -1,64 +1,129 @@
 package v1
 
 import (
-	"GoShort/internal/db"
+	"GoShort/internal/metadata"
+	"GoShort/internal/metrics"
 	"GoShort/internal/models"
+	"GoShort/internal/store"
 	"GoShort/internal/utils"
+	"GoShort/pkg/config"
+	"GoShort/pkg/logger"
+	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
-	"regexp"
 	"time"
 )
 
 // ShortenRequest represents the request payload for URL shortening
 type ShortenRequest struct {
-	LongURL   string `json:"long_url"`
-	CustomURL string `json:"custom_url,omitempty"`
-	Expiry    string `json:"expiry,omitempty"` // Optional expiry date
+	LongURL       string            `json:"long_url"`
+	CustomURL     string            `json:"custom_url,omitempty"`
+	Domain        string            `json:"domain,omitempty"`         // Optional custom domain, defaults to the one the request was sent to
+	Expiry        string            `json:"expiry,omitempty"`         // Optional expiry date
+	Variants      []VariantRequest  `json:"variants,omitempty"`       // Optional weighted destinations for split testing
+	ActivatesAt   string            `json:"activates_at,omitempty"`   // Optional activation date
+	Schedule      []ScheduleRequest `json:"schedule,omitempty"`       // Optional scheduled destinations
+	Preview       bool              `json:"preview,omitempty"`        // Show the preview page instead of redirecting
+	OGTitle       string            `json:"og_title,omitempty"`       // Optional social card title
+	OGDescription string            `json:"og_description,omitempty"` // Optional social card description
+	OGImage       string            `json:"og_image,omitempty"`       // Optional social card image URL
 }
 
+// Limits on the social card metadata accepted for a short URL
+const (
+	maxOGTitleLength       = 200
+	maxOGDescriptionLength = 500
+)
+
+// Links stores the short links created and served by the API
+var Links store.LinkStore
+
+// generateAttempts is how many generated short URLs are tried before giving up on collisions
+const generateAttempts = 5
+
+// MetadataFetcher fetches destination page metadata after a URL is stored; nil disables fetching
+var MetadataFetcher *metadata.Fetcher
+
 // ShortenResponse represents the response payload for URL shortening
 type ShortenResponse struct {
 	ShortURL string `json:"short_url"`
 }
 
-// validateCustomURL ensures the custom URL does not contain spaces or illegal characters
-func validateCustomURL(customURL string) bool {
-	// Define a regex for allowed characters (alphanumeric and dashes/underscores only)
-	validURLPattern := `^[a-zA-Z0-9_-]+$`
-	re := regexp.MustCompile(validURLPattern)
-	return re.MatchString(customURL)
+// buildVariants validates the requested variants and converts them to models.
+// When no weights are given the traffic is split evenly; otherwise variants
+// with a zero weight are stored but never served.
+func buildVariants(reqs []VariantRequest) ([]models.URLVariant, bool) {
+	if len(reqs) > maxVariants {
+		return nil, false
+	}
+
+	variants := make([]models.URLVariant, 0, len(reqs))
+	total := 0
+	for _, v := range reqs {
+		if !utils.ValidateURL(v.LongURL) || v.Weight < 0 {
+			return nil, false
+		}
+		total += v.Weight
+		variants = append(variants, models.URLVariant{LongURL: v.LongURL, Weight: v.Weight})
+	}
+	if total == 0 {
+		for i := range variants {
+			variants[i].Weight = 1
+		}
+	}
+	return variants, true
+}
+
+// shortenError describes why a shorten request was rejected
+type shortenError struct {
+	Status  int    // HTTP status code
+	Code    string // Machine-readable error code
+	Message string // Human-readable error message
 }
 
-// ShortenURL handles the URL shortening request
-func ShortenURL(w http.ResponseWriter, r *http.Request) {
-	var req ShortenRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request payload", http.StatusBadRequest)
-		return
+// Error codes returned for rejected shorten requests
+const (
+	codeInvalidPayload   = "invalid_payload"
+	codeInvalidURL       = "invalid_url"
+	codeInvalidCustomURL = "invalid_custom_url"
+	codeInvalidDomain    = "invalid_domain"
+	codeCustomURLTaken   = "custom_url_taken"
+	codeInvalidExpiry    = "invalid_expiry"
+	codeInvalidVariants  = "invalid_variants"
+	codeInvalidSchedule  = "invalid_schedule"
+	codeInvalidMetadata  = "invalid_metadata"
+	codeUnsafeURL        = "unsafe_url"
+	codeScannerDown      = "scanner_unavailable"
+	codeDatabaseDown     = "database_unavailable"
+	codeInternalError    = "internal_error"
+)
+
+// newShortenError creates a shortenError
+func newShortenError(status int, code, message string) *shortenError {
+	return &shortenError{Status: status, Code: code, Message: message}
+}
+
+// buildURL validates a shorten request and converts it to a model without touching the database,
+// applying the link lifetime policy. ShortURL is left empty when no custom URL was requested.
+func buildURL(req ShortenRequest, links config.LinksConfig) (*models.URL, *shortenError) {
+	// Validate the variants; the first one doubles as the primary destination
+	variants, ok := buildVariants(req.Variants)
+	if !ok {
+		return nil, newShortenError(http.StatusBadRequest, codeInvalidVariants, "Invalid variants")
+	}
+	if req.LongURL == "" && len(variants) > 0 {
+		req.LongURL = variants[0].LongURL
 	}
 
 	// Validate the long URL
 	if !utils.ValidateURL(req.LongURL) {
-		http.Error(w, "Invalid URL format", http.StatusBadRequest)
-		return
+		return nil, newShortenError(http.StatusBadRequest, codeInvalidURL, "Invalid URL format")
 	}
 
-	// Use custom URL if provided, otherwise generate a new one
-	shortURL := req.CustomURL
-	if shortURL != "" {
-		if !validateCustomURL(shortURL) {
-			http.Error(w, "Custom URL contains invalid characters", http.StatusBadRequest)
-			return
-		}
-		// Check if the custom URL already exists
-		var existingURL models.URL
-		if err := db.DB.Where("short_url = ?", shortURL).First(&existingURL).Error; err == nil {
-			http.Error(w, "Custom URL is already taken", http.StatusConflict)
-			return
-		}
-	} else {
-		shortURL = utils.GenerateShortURL()
+	// Validate the custom URL if provided
+	if req.CustomURL != "" && !utils.ValidateCustomShortURL(req.CustomURL) {
+		return nil, newShortenError(http.StatusBadRequest, codeInvalidCustomURL, "Custom URL contains invalid characters or is reserved")
 	}
 
 	// Parse expiry if provided
@@ -66,26 +131,185 @@ func ShortenURL(w http.ResponseWriter, r *http.Request) {
 	if req.Expiry != "" {
 		parsedExpiry, err := time.Parse(time.RFC3339, req.Expiry)
 		if err != nil {
-			http.Error(w, "Invalid expiry format", http.StatusBadRequest)
-			return
+			return nil, newShortenError(http.StatusBadRequest, codeInvalidExpiry, "Invalid expiry format")
 		}
 		expiry = &parsedExpiry
 	}
 
-	// Save the URL to the database
-	url := models.URL{
-		LongURL:  req.LongURL,
-		ShortURL: shortURL,
-		Expiry:   expiry,
+	// Apply the instance's link lifetime policy
+	now := time.Now()
+	if expiry == nil && links.DefaultTTL > 0 {
+		defaultExpiry := now.Add(time.Duration(links.DefaultTTL))
+		expiry = &defaultExpiry
+	}
+	if expiry != nil && links.MaxTTL > 0 && expiry.After(now.Add(time.Duration(links.MaxTTL))) {
+		return nil, newShortenError(http.StatusBadRequest, codeInvalidExpiry, "Expiry exceeds the maximum link lifetime")
+	}
+
+	// Parse activation date if provided
+	var activatesAt *time.Time
+	if req.ActivatesAt != "" {
+		parsedActivatesAt, err := time.Parse(time.RFC3339, req.ActivatesAt)
+		if err != nil {
+			return nil, newShortenError(http.StatusBadRequest, codeInvalidSchedule, "Invalid activation format")
+		}
+		if expiry != nil && !parsedActivatesAt.Before(*expiry) {
+			return nil, newShortenError(http.StatusBadRequest, codeInvalidSchedule, "Activation must be before expiry")
+		}
+		activatesAt = &parsedActivatesAt
+	}
+
+	// Validate the social card metadata
+	if req.OGImage != "" && !utils.ValidateURL(req.OGImage) {
+		return nil, newShortenError(http.StatusBadRequest, codeInvalidMetadata, "Invalid OpenGraph image URL")
+	}
+	if len(req.OGTitle) > maxOGTitleLength || len(req.OGDescription) > maxOGDescriptionLength {
+		return nil, newShortenError(http.StatusBadRequest, codeInvalidMetadata, "OpenGraph metadata is too long")
+	}
+
+	// Validate the scheduled destinations
+	schedules, err := buildSchedules(req.Schedule)
+	if err != nil {
+		return nil, newShortenError(http.StatusBadRequest, codeInvalidSchedule, err.Error())
+	}
+
+	return &models.URL{
+		LongURL:       req.LongURL,
+		ShortURL:      req.CustomURL,
+		Expiry:        expiry,
+		ActivatesAt:   activatesAt,
+		Variants:      variants,
+		Schedules:     schedules,
+		Preview:       req.Preview,
+		OGTitle:       req.OGTitle,
+		OGDescription: req.OGDescription,
+		OGImage:       req.OGImage,
+	}, nil
+}
+
+// scanDestinations checks every destination of url with the malicious URL scanner
+func scanDestinations(ctx context.Context, url *models.URL, scanner config.ScannerConfig) *shortenError {
+	if !scanner.Enabled {
+		return nil
+	}
+
+	destinations := []string{url.LongURL}
+	for _, v := range url.Variants {
+		destinations = append(destinations, v.LongURL)
+	}
+	for _, s := range url.Schedules {
+		destinations = append(destinations, s.LongURL)
+	}
+
+	scanned := make(map[string]bool, len(destinations))
+	for _, destination := range destinations {
+		if scanned[destination] {
+			continue
+		}
+		scanned[destination] = true
+
+		safe, err := utils.CheckMaliciousURL(ctx, scanner.Endpoint, scanner.APIKey, destination)
+		if err != nil {
+			logger.ErrorContext(ctx, "Failed to scan URL", logger.Fields{"url": logger.RedactURL(destination), "error": err})
+			if scanner.FailOpen {
+				continue
+			}
+			return newShortenError(http.StatusServiceUnavailable, codeScannerDown, "URL scanner is unavailable")
+		}
+		if !safe {
+			return newShortenError(http.StatusBadRequest, codeUnsafeURL, "URL was flagged as unsafe")
+		}
+	}
+	return nil
+}
+
+// resolveShortenDomain returns the namespace a shorten request is stored in: the requested
+// custom domain, or else the one the request was sent to
+func resolveShortenDomain(r *http.Request, requested string) (string, *shortenError) {
+	var domain *models.Domain
+	var err error
+	if requested == "" {
+		domain, err = requestDomain(r)
+	} else {
+		domain, err = findDomain(r.Context(), requested)
+	}
+	if err != nil {
+		return "", newShortenError(http.StatusServiceUnavailable, codeDatabaseDown, "Failed to look up domain")
 	}
-	if err := db.DB.Create(&url).Error; err != nil {
-		http.Error(w, "Failed to save URL", http.StatusInternalServerError)
+	if requested == "" {
+		return domainHost(domain), nil
+	}
+	if domain == nil {
+		return "", newShortenError(http.StatusBadRequest, codeInvalidDomain, "Unknown domain")
+	}
+	return domain.Host, nil
+}
+
+// createLink stores url, generating a short URL when none was requested and retrying on the
+// unlikely collision with an existing one
+func createLink(ctx context.Context, url *models.URL) error {
+	if url.ShortURL != "" {
+		return Links.CreateLink(ctx, url)
+	}
+	var err error
+	for attempt := 0; attempt < generateAttempts; attempt++ {
+		url.ShortURL = utils.GenerateShortURL()
+		if err = Links.CreateLink(ctx, url); !errors.Is(err, store.ErrConflict) {
+			return err
+		}
+	}
+	return err
+}
+
+// failShorten answers a rejected shorten request and counts the reason
+func failShorten(w http.ResponseWriter, err *shortenError) {
+	metrics.Shortens.WithLabelValues(err.Code).Inc()
+	http.Error(w, err.Message, err.Status)
+}
+
+// ShortenURL handles the URL shortening request
+func ShortenURL(w http.ResponseWriter, r *http.Request) {
+	var req ShortenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		failShorten(w, newShortenError(http.StatusBadRequest, codeInvalidPayload, "Invalid request payload"))
 		return
 	}
 
+	cfg := config.FromContext(r.Context())
+	url, shortenErr := buildURL(req, cfg.Links)
+	if shortenErr != nil {
+		failShorten(w, shortenErr)
+		return
+	}
+	if shortenErr = scanDestinations(r.Context(), url, cfg.Scanner); shortenErr != nil {
+		failShorten(w, shortenErr)
+		return
+	}
+	if url.Domain, shortenErr = resolveShortenDomain(r, req.Domain); shortenErr != nil {
+		failShorten(w, shortenErr)
+		return
+	}
+
+	// Save the URL, using the custom URL if provided
+	err := createLink(r.Context(), url)
+	if errors.Is(err, store.ErrConflict) && req.CustomURL != "" {
+		failShorten(w, newShortenError(http.StatusConflict, codeCustomURLTaken, "Custom URL is already taken"))
+		return
+	}
+	if err != nil {
+		failShorten(w, newShortenError(http.StatusInternalServerError, codeInternalError, "Failed to save URL"))
+		return
+	}
+	metrics.Shortens.WithLabelValues(metrics.ShortenSuccess).Inc()
+
+	// Fetch the destination title and favicon in the background
+	if MetadataFetcher != nil {
+		MetadataFetcher.Enqueue(url)
+	}
+
 	// Return the shortened URL
 	resp := ShortenResponse{
-		ShortURL: shortURL,
+		ShortURL: url.ShortURL,
 	}
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(resp)
@@ -1,7 +1,14 @@
 package v1
 
 import (
+	"GoShort/internal/models"
 	"GoShort/internal/utils"
+	"GoShort/pkg/config"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
@@ -33,3 +40,79 @@ func TestParseExpiry(t *testing.T) {
 	_, err = time.Parse(time.RFC3339, invalidExpiry)
 	assert.Error(t, err, "Expected invalid expiry to return an error")
 }
+
+func TestBuildURLAppliesLinkTTLs(t *testing.T) {
+	links := config.LinksConfig{DefaultTTL: config.Duration(24 * time.Hour), MaxTTL: config.Duration(48 * time.Hour)}
+
+	url, err := buildURL(ShortenRequest{LongURL: "https://example.com"}, links)
+	assert.Nil(t, err)
+	if assert.NotNil(t, url.Expiry) {
+		assert.WithinDuration(t, time.Now().Add(24*time.Hour), *url.Expiry, time.Minute)
+	}
+
+	tooLate := time.Now().Add(72 * time.Hour).UTC().Format(time.RFC3339)
+	_, err = buildURL(ShortenRequest{LongURL: "https://example.com", Expiry: tooLate}, links)
+	if assert.NotNil(t, err) {
+		assert.Equal(t, codeInvalidExpiry, err.Code)
+	}
+}
+
+func TestScanDestinations(t *testing.T) {
+	scanner := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]string
+		json.NewDecoder(r.Body).Decode(&req)
+		json.NewEncoder(w).Encode(utils.URLScanResponse{Safe: !strings.Contains(req["url"], "malware")})
+	}))
+	defer scanner.Close()
+
+	cfg := config.ScannerConfig{Enabled: true, Endpoint: scanner.URL, APIKey: "key"}
+	assert.Nil(t, scanDestinations(context.Background(), &models.URL{LongURL: "https://example.com"}, cfg))
+
+	err := scanDestinations(context.Background(), &models.URL{
+		LongURL:  "https://example.com",
+		Variants: []models.URLVariant{{LongURL: "https://malware.example.com"}},
+	}, cfg)
+	if assert.NotNil(t, err) {
+		assert.Equal(t, codeUnsafeURL, err.Code)
+	}
+
+	// An unreachable scanner rejects links unless configured to fail open
+	scanner.Close()
+	err = scanDestinations(context.Background(), &models.URL{LongURL: "https://example.com"}, cfg)
+	if assert.NotNil(t, err) {
+		assert.Equal(t, http.StatusServiceUnavailable, err.Status)
+	}
+	cfg.FailOpen = true
+	assert.Nil(t, scanDestinations(context.Background(), &models.URL{LongURL: "https://example.com"}, cfg))
+}
+
+func TestShortenURL(t *testing.T) {
+	loadEnvConfig(t)
+	links := useMemoryStore(t)
+	shorten := func(body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		ShortenURL(w, httptest.NewRequest(http.MethodPost, "/v1/shorten", strings.NewReader(body)))
+		return w
+	}
+
+	w := shorten(`{"long_url": "https://example.com", "custom_url": "promo"}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	url, err := links.FindLink(context.Background(), "", "promo")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://example.com", url.LongURL)
+
+	w = shorten(`{"long_url": "https://example.org", "custom_url": "promo"}`)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	w = shorten(`{"long_url": "https://example.org"}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var resp ShortenResponse
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	assert.Len(t, resp.ShortURL, 8)
+
+	w = shorten(`{"long_url": "not a url"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = shorten(`{"long_url": "https://example.com", "custom_url": "Healthz"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code, "Expected reserved paths to be rejected")
+}
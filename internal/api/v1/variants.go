@@ -0,0 +1,103 @@
+package v1
+
+import (
+	"GoShort/internal/models"
+	"encoding/json"
+	"math/rand"
+	"net/http"
+	"strconv"
+)
+
+// maxVariants limits how many destinations a single short URL can rotate between
+const maxVariants = 10
+
+// variantCookieMaxAge keeps a visitor on the same variant for 30 days
+const variantCookieMaxAge = 30 * 24 * 60 * 60
+
+// VariantRequest represents one weighted destination in a shorten request
+type VariantRequest struct {
+	LongURL string `json:"long_url"`
+	Weight  int    `json:"weight"`
+}
+
+// VariantResponse represents a destination and its click count
+type VariantResponse struct {
+	ID      uint   `json:"id"`
+	LongURL string `json:"long_url"`
+	Weight  int    `json:"weight"`
+	Clicks  int    `json:"clicks"`
+}
+
+// variantCookieName returns the cookie used to pin a visitor to a variant of shortURL
+func variantCookieName(shortURL string) string {
+	return "gs_variant_" + shortURL
+}
+
+// pickVariant selects a destination for the visitor, honouring a previously assigned
+// variant from the request cookie and otherwise choosing at random by weight
+func pickVariant(r *http.Request, shortURL string, variants []models.URLVariant) *models.URLVariant {
+	if cookie, err := r.Cookie(variantCookieName(shortURL)); err == nil {
+		if id, err := strconv.ParseUint(cookie.Value, 10, 64); err == nil {
+			for i := range variants {
+				if uint64(variants[i].ID) == id && variants[i].Weight > 0 {
+					return &variants[i]
+				}
+			}
+		}
+	}
+
+	total := 0
+	for _, v := range variants {
+		if v.Weight > 0 {
+			total += v.Weight
+		}
+	}
+	if total == 0 {
+		return nil
+	}
+
+	n := rand.Intn(total)
+	for i := range variants {
+		if variants[i].Weight <= 0 {
+			continue
+		}
+		if n < variants[i].Weight {
+			return &variants[i]
+		}
+		n -= variants[i].Weight
+	}
+	return nil
+}
+
+// setVariantCookie pins the visitor to the given variant on subsequent visits
+func setVariantCookie(w http.ResponseWriter, shortURL string, variant *models.URLVariant) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     variantCookieName(shortURL),
+		Value:    strconv.FormatUint(uint64(variant.ID), 10),
+		Path:     "/" + shortURL,
+		MaxAge:   variantCookieMaxAge,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// GetVariants returns the destinations of a short URL together with their click counts
+func GetVariants(w http.ResponseWriter, r *http.Request) {
+	url, _, ok := findURL(w, r, "Variants")
+	if !ok {
+		return
+	}
+
+	resp := make([]VariantResponse, 0, len(url.Variants))
+	for _, v := range url.Variants {
+		resp = append(resp, VariantResponse{
+			ID:      v.ID,
+			LongURL: v.LongURL,
+			Weight:  v.Weight,
+			Clicks:  v.Clicks,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,65 @@
+package v1
+
+import (
+	"GoShort/internal/models"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPickVariantHonoursCookie(t *testing.T) {
+	variants := []models.URLVariant{
+		{ID: 1, LongURL: "https://a.example.com", Weight: 1},
+		{ID: 2, LongURL: "https://b.example.com", Weight: 1},
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/promo", nil)
+	req.AddCookie(&http.Cookie{Name: variantCookieName("promo"), Value: "2"})
+
+	for i := 0; i < 20; i++ {
+		variant := pickVariant(req, "promo", variants)
+		assert.NotNil(t, variant)
+		assert.Equal(t, uint(2), variant.ID, "Expected cookie to pin the visitor to variant 2")
+	}
+}
+
+func TestPickVariantSkipsZeroWeight(t *testing.T) {
+	variants := []models.URLVariant{
+		{ID: 1, LongURL: "https://a.example.com", Weight: 0},
+		{ID: 2, LongURL: "https://b.example.com", Weight: 3},
+	}
+
+	// A cookie for a paused variant must not be honoured
+	req := httptest.NewRequest(http.MethodGet, "/promo", nil)
+	req.AddCookie(&http.Cookie{Name: variantCookieName("promo"), Value: "1"})
+
+	for i := 0; i < 20; i++ {
+		variant := pickVariant(req, "promo", variants)
+		assert.NotNil(t, variant)
+		assert.Equal(t, uint(2), variant.ID)
+	}
+}
+
+func TestPickVariantNoVariants(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/promo", nil)
+	assert.Nil(t, pickVariant(req, "promo", nil))
+}
+
+func TestBuildVariants(t *testing.T) {
+	variants, ok := buildVariants([]VariantRequest{
+		{LongURL: "https://a.example.com"},
+		{LongURL: "https://b.example.com"},
+	})
+	assert.True(t, ok)
+	assert.Len(t, variants, 2)
+	assert.Equal(t, 1, variants[0].Weight, "Expected unweighted variants to be split evenly")
+	assert.Equal(t, 1, variants[1].Weight, "Expected unweighted variants to be split evenly")
+
+	_, ok = buildVariants([]VariantRequest{{LongURL: "invalid-url", Weight: 1}})
+	assert.False(t, ok, "Expected invalid variant URL to be rejected")
+
+	_, ok = buildVariants([]VariantRequest{{LongURL: "https://a.example.com", Weight: -1}})
+	assert.False(t, ok, "Expected negative weight to be rejected")
+}
@@ -1,34 +1,136 @@
 package db
 
 import (
-	"GoShort/internal/models"
+	"GoShort/internal/tracing"
 	"GoShort/pkg/config"
-	"log"
+	"GoShort/pkg/logger"
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/glebarez/sqlite"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
+// sqlitePragmas are applied to SQLite databases opened without options of their own, so that
+// concurrent requests wait for the write lock instead of failing
+const sqlitePragmas = "_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_pragma=foreign_keys(1)"
+
+// Delays between attempts to reach a database that is not up yet
+const (
+	initialRetryDelay = 500 * time.Millisecond
+	maxRetryDelay     = 10 * time.Second
+)
+
 var DB *gorm.DB
 
-// InitDB initializes the database connection and applies migrations
+// InitDB initializes the database connection. The schema is managed by the migrations package.
 func InitDB() {
-	// Load the database connection string from environment variables
-	dsn := config.Get("DATABASE_URL")
+	cfg := config.Current().Database
 
-	// Connect to the database
+	// Connect to the database, waiting for it to come up
 	var err error
-	DB, err = gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	DB, err = connect(cfg)
+	if err != nil {
+		logger.Fatal("Failed to connect to the database", logger.Fields{"error": err})
+	}
+
+	// Size the connection pool
+	if err := configurePool(DB, cfg); err != nil {
+		logger.Fatal("Failed to access the database pool", logger.Fields{"error": err})
+	}
+
+	// Connect to the read replicas serving redirects and stats
+	openReplicas(cfg)
+
+	logger.Info("Database connection initialized", nil)
+}
+
+// connect opens the primary database, retrying with exponential backoff until
+// cfg.ConnectTimeout has passed so that the server survives the database starting late
+func connect(cfg config.DatabaseConfig) (*gorm.DB, error) {
+	deadline := time.Now().Add(time.Duration(cfg.ConnectTimeout))
+	delay := initialRetryDelay
+	for attempt := 1; ; attempt++ {
+		conn, err := gorm.Open(dialector(withStatementTimeout(cfg.URL, time.Duration(cfg.StatementTimeout))), &gorm.Config{TranslateError: true, Logger: gormLogger{}})
+		if err == nil {
+			return conn, conn.Use(tracing.GORM())
+		}
+		if time.Now().Add(delay).After(deadline) {
+			return nil, err
+		}
+		logger.Warn("Failed to connect to the database, retrying", logger.Fields{"attempt": attempt, "retry_in": delay.String(), "error": err})
+		time.Sleep(delay)
+		delay = min(delay*2, maxRetryDelay)
+	}
+}
+
+// withStatementTimeout adds the statement timeout to a Postgres connection string, unless
+// it sets one already. SQLite has no statement timeout.
+func withStatementTimeout(url string, timeout time.Duration) string {
+	if timeout <= 0 || strings.HasPrefix(url, "sqlite://") || strings.Contains(url, "statement_timeout") {
+		return url
+	}
+	ms := strconv.FormatInt(timeout.Milliseconds(), 10)
+	if !strings.HasPrefix(url, "postgres://") && !strings.HasPrefix(url, "postgresql://") {
+		return url + " statement_timeout=" + ms
+	}
+	if strings.Contains(url, "?") {
+		return url + "&statement_timeout=" + ms
+	}
+	return url + "?statement_timeout=" + ms
+}
+
+// configurePool sizes the connection pool of conn
+func configurePool(conn *gorm.DB, cfg config.DatabaseConfig) error {
+	sqlDB, err := conn.DB()
 	if err != nil {
-		log.Fatalf("Failed to connect to the database: %v", err)
+		return err
 	}
+	sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(time.Duration(cfg.ConnMaxLifetime))
+	sqlDB.SetConnMaxIdleTime(time.Duration(cfg.ConnMaxIdleTime))
+	return nil
+}
 
-	// Apply migrations
-	log.Println("Running migrations...")
-	if err := DB.AutoMigrate(&models.URL{}); err != nil {
-		log.Fatalf("Failed to migrate URL schema: %v", err)
+// dialector picks the database driver from the URL: sqlite:// URLs open an embedded SQLite
+// database file, anything else is a Postgres connection string
+func dialector(url string) gorm.Dialector {
+	path, ok := strings.CutPrefix(url, "sqlite://")
+	if !ok {
+		return postgres.Open(url)
+	}
+	if !strings.Contains(path, "?") {
+		path += "?" + sqlitePragmas
 	}
-	log.Println("Migrations completed successfully.")
+	return sqlite.Open(path)
+}
 
-	log.Println("Database connection initialized successfully.")
+// Ping checks that the primary database can be reached
+func Ping(ctx context.Context) error {
+	if DB == nil {
+		return errors.New("database is not connected")
+	}
+	sqlDB, err := DB.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
+
+// Close closes the database connection pools
+func Close() error {
+	closeReplicas()
+	if DB == nil {
+		return nil
+	}
+	sqlDB, err := DB.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
 }
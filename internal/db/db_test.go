@@ -0,0 +1,41 @@
+package db
+
+import (
+	"GoShort/pkg/config"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithStatementTimeout(t *testing.T) {
+	tests := []struct {
+		url      string
+		timeout  time.Duration
+		expected string
+	}{
+		{"postgres://db/goshort", 30 * time.Second, "postgres://db/goshort?statement_timeout=30000"},
+		{"postgresql://db/goshort?sslmode=disable", time.Second, "postgresql://db/goshort?sslmode=disable&statement_timeout=1000"},
+		{"host=db dbname=goshort", 5 * time.Second, "host=db dbname=goshort statement_timeout=5000"},
+		{"postgres://db/goshort?statement_timeout=100", 30 * time.Second, "postgres://db/goshort?statement_timeout=100"},
+		{"postgres://db/goshort", 0, "postgres://db/goshort"},
+		{"sqlite:///var/lib/goshort.db", 30 * time.Second, "sqlite:///var/lib/goshort.db"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			assert.Equal(t, tt.expected, withStatementTimeout(tt.url, tt.timeout))
+		})
+	}
+}
+
+func TestConnectGivesUp(t *testing.T) {
+	start := time.Now()
+	_, err := connect(config.DatabaseConfig{
+		URL:            "postgres://goshort@127.0.0.1:1/goshort?sslmode=disable&connect_timeout=1",
+		ConnectTimeout: config.Duration(time.Second),
+	})
+	assert.Error(t, err)
+	assert.Less(t, time.Since(start), 5*time.Second, "Retries stop once the connect timeout has passed")
+	assert.GreaterOrEqual(t, time.Since(start), initialRetryDelay, "Failed connections are retried")
+}
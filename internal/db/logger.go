@@ -0,0 +1,66 @@
+package db
+
+import (
+	"GoShort/pkg/logger"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// slowQueryThreshold is how long a query may take before it is logged as slow
+const slowQueryThreshold = 200 * time.Millisecond
+
+// gormLogger sends GORM's messages to the structured logger. Queries are logged with their
+// placeholders only, since their arguments hold destination URLs.
+type gormLogger struct{}
+
+// LogMode is a no-op: the level is set on the structured logger
+func (l gormLogger) LogMode(gormlogger.LogLevel) gormlogger.Interface {
+	return l
+}
+
+// Info logs an informational message from GORM
+func (gormLogger) Info(ctx context.Context, msg string, data ...interface{}) {
+	logger.InfoContext(ctx, fmt.Sprintf(msg, data...), nil)
+}
+
+// Warn logs a warning from GORM
+func (gormLogger) Warn(ctx context.Context, msg string, data ...interface{}) {
+	logger.WarnContext(ctx, fmt.Sprintf(msg, data...), nil)
+}
+
+// Error logs an error from GORM
+func (gormLogger) Error(ctx context.Context, msg string, data ...interface{}) {
+	logger.ErrorContext(ctx, fmt.Sprintf(msg, data...), nil)
+}
+
+// Trace logs failed and slow queries, and every query at the debug level
+func (gormLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	elapsed := time.Since(begin)
+	failed := err != nil && !errors.Is(err, gorm.ErrRecordNotFound)
+	slow := elapsed > slowQueryThreshold
+	if !failed && !slow && !logger.DebugEnabled() {
+		return
+	}
+
+	sql, rows := fc()
+	fields := logger.Fields{"sql": sql, "rows": rows, "latency_ms": float64(elapsed.Microseconds()) / 1000}
+	switch {
+	case failed:
+		fields["error"] = err
+		logger.ErrorContext(ctx, "Query failed", fields)
+	case slow:
+		logger.WarnContext(ctx, "Slow query", fields)
+	default:
+		logger.DebugContext(ctx, "Query", fields)
+	}
+}
+
+// ParamsFilter drops the query arguments from logged SQL
+func (gormLogger) ParamsFilter(ctx context.Context, sql string, params ...interface{}) (string, []interface{}) {
+	return sql, nil
+}
@@ -0,0 +1,32 @@
+package db
+
+import (
+	"GoShort/pkg/logger"
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestGormLogger(t *testing.T) {
+	var out bytes.Buffer
+	logger.Init()
+	logger.SetOutput(&out)
+	t.Cleanup(logger.Init)
+
+	conn := openSQLite(t, "goshort.db").Session(&gorm.Session{Logger: gormLogger{}})
+	assert.NoError(t, conn.Exec("CREATE TABLE urls (long_url TEXT NOT NULL)").Error)
+	assert.Equal(t, "", out.String(), "Successful queries are only logged at the debug level")
+
+	// Failed queries are logged without their arguments, which hold destination URLs
+	assert.Error(t, conn.Exec("INSERT INTO link (long_url) VALUES (?)", "https://example.com/?token=abc").Error)
+	assert.Contains(t, out.String(), "Query failed")
+	assert.Contains(t, out.String(), "INSERT INTO link (long_url) VALUES (?)")
+	assert.NotContains(t, out.String(), "example.com")
+
+	// Missing records are expected and not logged
+	out.Reset()
+	assert.ErrorIs(t, conn.Table("urls").First(&struct{ LongURL string }{}).Error, gorm.ErrRecordNotFound)
+	assert.Equal(t, "", out.String())
+}
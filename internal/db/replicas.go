@@ -0,0 +1,153 @@
+package db
+
+import (
+	"GoShort/internal/tracing"
+	"GoShort/pkg/config"
+	"GoShort/pkg/logger"
+	"context"
+	"database/sql"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// replicaCheckTimeout bounds a single replica health check
+const replicaCheckTimeout = 2 * time.Second
+
+// replica is a read-only database whose health is checked in the background
+type replica struct {
+	index   int
+	db      *gorm.DB
+	healthy atomic.Bool
+}
+
+var (
+	replicas    []*replica
+	nextReplica atomic.Uint64
+)
+
+// Reader returns the database to run read-only queries on: a healthy read replica, taking
+// turns between them, or the primary when none is configured or healthy. Replicas may lag
+// behind the primary, so callers must tolerate not seeing the latest writes.
+func Reader() *gorm.DB {
+	start := nextReplica.Add(1)
+	for i := range replicas {
+		r := replicas[(start+uint64(i))%uint64(len(replicas))]
+		if r.healthy.Load() {
+			return r.db
+		}
+	}
+	return DB
+}
+
+// Read runs query on the database returned by Reader. When a replica fails the query with
+// anything but a missing row, it is marked unhealthy and query runs again on the primary.
+func Read(ctx context.Context, query func(*gorm.DB) error) error {
+	conn := Reader()
+	err := query(conn.WithContext(ctx))
+	if err == nil || conn == DB || errors.Is(err, gorm.ErrRecordNotFound) || ctx.Err() != nil {
+		return err
+	}
+	ReplicaFailed(conn, err)
+	return query(DB.WithContext(ctx))
+}
+
+// ReplicaFailed marks the read replica conn unhealthy after it failed a query, so that reads
+// go elsewhere until its next health check succeeds
+func ReplicaFailed(conn *gorm.DB, err error) {
+	for _, r := range replicas {
+		if r.db == conn && r.healthy.Swap(false) {
+			logger.Warn("Read replica failed a query, falling back", logger.Fields{"replica": r.index, "error": err})
+		}
+	}
+}
+
+// openReplicas connects to the configured read replicas. Replicas that cannot be reached
+// yet are kept but only used once a health check succeeds.
+func openReplicas(cfg config.DatabaseConfig) {
+	for i, url := range cfg.Replicas {
+		conn, err := gorm.Open(dialector(withStatementTimeout(url, time.Duration(cfg.StatementTimeout))), &gorm.Config{TranslateError: true, DisableAutomaticPing: true, Logger: gormLogger{}})
+		if err != nil {
+			logger.Error("Failed to open read replica", logger.Fields{"replica": i, "error": err})
+			continue
+		}
+		if err := conn.Use(tracing.GORM()); err != nil {
+			logger.Error("Failed to trace read replica", logger.Fields{"replica": i, "error": err})
+			continue
+		}
+		if err := configurePool(conn, cfg); err != nil {
+			logger.Error("Failed to access the pool of read replica", logger.Fields{"replica": i, "error": err})
+			continue
+		}
+
+		r := &replica{index: i, db: conn}
+		r.check(context.Background())
+		replicas = append(replicas, r)
+	}
+	if len(cfg.Replicas) > 0 {
+		logger.Info("Read replicas connected", logger.Fields{"replicas": len(replicas)})
+	}
+}
+
+// WatchReplicas checks the health of the read replicas every interval until ctx is done
+func WatchReplicas(ctx context.Context, interval time.Duration) {
+	if len(replicas) == 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for _, r := range replicas {
+				r.check(ctx)
+			}
+		}
+	}
+}
+
+// check pings the replica and updates its health, logging changes
+func (r *replica) check(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, replicaCheckTimeout)
+	defer cancel()
+
+	sqlDB, err := r.db.DB()
+	if err == nil {
+		err = sqlDB.PingContext(ctx)
+	}
+	healthy := err == nil
+	if r.healthy.Swap(healthy) == healthy {
+		return
+	}
+	if healthy {
+		logger.Info("Read replica is healthy", logger.Fields{"replica": r.index})
+	} else {
+		logger.Warn("Read replica is unhealthy, falling back", logger.Fields{"replica": r.index, "error": err})
+	}
+}
+
+// ReplicaPools returns the connection pools of the read replicas, in configuration order
+func ReplicaPools() []*sql.DB {
+	var pools []*sql.DB
+	for _, r := range replicas {
+		if sqlDB, err := r.db.DB(); err == nil {
+			pools = append(pools, sqlDB)
+		}
+	}
+	return pools
+}
+
+// closeReplicas closes the connection pools of the read replicas
+func closeReplicas() {
+	for _, r := range replicas {
+		if sqlDB, err := r.db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	}
+	replicas = nil
+}
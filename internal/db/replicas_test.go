@@ -0,0 +1,90 @@
+package db
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+// useReplicas makes the given databases the read replicas for the duration of the test
+func useReplicas(t *testing.T, conns ...*gorm.DB) {
+	primary, previous := DB, replicas
+	t.Cleanup(func() { DB, replicas = primary, previous })
+
+	replicas = nil
+	for i, conn := range conns {
+		replicas = append(replicas, &replica{index: i, db: conn})
+	}
+}
+
+// openSQLite opens a fresh SQLite database
+func openSQLite(t *testing.T, name string) *gorm.DB {
+	conn, err := gorm.Open(dialector("sqlite://"+filepath.Join(t.TempDir(), name)), &gorm.Config{})
+	assert.NoError(t, err)
+	t.Cleanup(func() {
+		sqlDB, _ := conn.DB()
+		sqlDB.Close()
+	})
+	return conn
+}
+
+func TestReader(t *testing.T) {
+	first, second := openSQLite(t, "first.db"), openSQLite(t, "second.db")
+	useReplicas(t, first, second)
+	DB = openSQLite(t, "primary.db")
+
+	// Replicas are only used once they are known to be healthy
+	assert.Same(t, DB, Reader())
+
+	for _, r := range replicas {
+		r.check(context.Background())
+	}
+	assert.ElementsMatch(t, []*gorm.DB{first, second}, []*gorm.DB{Reader(), Reader()})
+
+	// Unreachable replicas are skipped, and the primary serves reads once none is left
+	sqlDB, _ := first.DB()
+	sqlDB.Close()
+	replicas[0].check(context.Background())
+	assert.Same(t, second, Reader())
+	assert.Same(t, second, Reader())
+
+	sqlDB, _ = second.DB()
+	sqlDB.Close()
+	replicas[1].check(context.Background())
+	assert.Same(t, DB, Reader())
+}
+
+func TestRead(t *testing.T) {
+	replica := openSQLite(t, "replica.db")
+	useReplicas(t, replica)
+	DB = openSQLite(t, "primary.db")
+	replicas[0].check(context.Background())
+	for _, conn := range []*gorm.DB{DB, replica} {
+		assert.NoError(t, conn.Exec("CREATE TABLE names (name TEXT)").Error)
+	}
+	assert.NoError(t, DB.Exec("INSERT INTO names VALUES ('primary')").Error)
+
+	name := func() (string, error) {
+		var row struct{ Name string }
+		err := Read(context.Background(), func(conn *gorm.DB) error {
+			return conn.Table("names").Take(&row).Error
+		})
+		return row.Name, err
+	}
+
+	// Reads go to the healthy replica
+	_, err := name()
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound, "The replica has no rows")
+	assert.True(t, replicas[0].healthy.Load(), "Missing rows do not make a replica unhealthy")
+
+	// A replica failing a query is taken out of rotation and the primary answers instead
+	assert.NoError(t, replica.Exec("DROP TABLE names").Error)
+	got, err := name()
+	assert.NoError(t, err)
+	assert.Equal(t, "primary", got)
+	assert.False(t, replicas[0].healthy.Load())
+	assert.Same(t, DB, Reader())
+}
@@ -0,0 +1,288 @@
+package exporter
+
+import (
+	"GoShort/internal/models"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Supported export formats
+const (
+	FormatCSV    = "csv"
+	FormatJSON   = "json"
+	FormatNDJSON = "ndjson"
+)
+
+// batchSize is the number of rows loaded from the database at a time
+const batchSize = 500
+
+// ContentType returns the media type of an export format
+func ContentType(format string) string {
+	switch format {
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatNDJSON:
+		return "application/x-ndjson"
+	}
+	return "application/json"
+}
+
+// ValidFormat checks if format is a supported export format
+func ValidFormat(format string) bool {
+	return format == FormatCSV || format == FormatJSON || format == FormatNDJSON
+}
+
+// Filter restricts an export to a time range; zero times are unbounded
+type Filter struct {
+	Since time.Time
+	Until time.Time
+}
+
+// apply adds the filter's conditions on column to query
+func (f Filter) apply(query *gorm.DB, column string) *gorm.DB {
+	if !f.Since.IsZero() {
+		query = query.Where(column+" >= ?", f.Since)
+	}
+	if !f.Until.IsZero() {
+		query = query.Where(column+" < ?", f.Until)
+	}
+	return query
+}
+
+// VariantRecord is an exported split-test destination
+type VariantRecord struct {
+	LongURL string `json:"long_url"`
+	Weight  int    `json:"weight"`
+	Clicks  int    `json:"clicks"`
+}
+
+// ScheduleRecord is an exported scheduled destination
+type ScheduleRecord struct {
+	LongURL  string    `json:"long_url"`
+	StartsAt time.Time `json:"starts_at"`
+}
+
+// LinkRecord is an exported short URL
+type LinkRecord struct {
+	Domain        string           `json:"domain,omitempty"`
+	ShortURL      string           `json:"short_url"`
+	LongURL       string           `json:"long_url"`
+	CreatedAt     time.Time        `json:"created_at"`
+	Expiry        *time.Time       `json:"expiry,omitempty"`
+	ActivatesAt   *time.Time       `json:"activates_at,omitempty"`
+	Clicks        int              `json:"clicks"`
+	Preview       bool             `json:"preview,omitempty"`
+	Title         string           `json:"title,omitempty"`
+	Description   string           `json:"description,omitempty"`
+	OGTitle       string           `json:"og_title,omitempty"`
+	OGDescription string           `json:"og_description,omitempty"`
+	OGImage       string           `json:"og_image,omitempty"`
+	Variants      []VariantRecord  `json:"variants,omitempty"`
+	Schedule      []ScheduleRecord `json:"schedule,omitempty"`
+}
+
+// linkCSVHeader lists the columns of a CSV link export. Variants and schedules are only
+// included in the JSON formats.
+var linkCSVHeader = []string{"short_url", "long_url", "created_at", "expiry", "activates_at", "clicks", "preview", "title", "description", "og_title", "og_description", "og_image", "domain"}
+
+// csvRow flattens the link into a CSV row
+func (l LinkRecord) csvRow() []string {
+	return []string{
+		l.ShortURL,
+		l.LongURL,
+		formatTime(&l.CreatedAt),
+		formatTime(l.Expiry),
+		formatTime(l.ActivatesAt),
+		strconv.Itoa(l.Clicks),
+		strconv.FormatBool(l.Preview),
+		l.Title,
+		l.Description,
+		l.OGTitle,
+		l.OGDescription,
+		l.OGImage,
+		l.Domain,
+	}
+}
+
+// newLinkRecord converts a stored URL into its exported form
+func newLinkRecord(url *models.URL) LinkRecord {
+	record := LinkRecord{
+		Domain:        url.Domain,
+		ShortURL:      url.ShortURL,
+		LongURL:       url.LongURL,
+		CreatedAt:     url.CreatedAt,
+		Expiry:        url.Expiry,
+		ActivatesAt:   url.ActivatesAt,
+		Clicks:        url.Clicks,
+		Preview:       url.Preview,
+		Title:         url.Title,
+		Description:   url.Description,
+		OGTitle:       url.OGTitle,
+		OGDescription: url.OGDescription,
+		OGImage:       url.OGImage,
+	}
+	for _, v := range url.Variants {
+		record.Variants = append(record.Variants, VariantRecord{LongURL: v.LongURL, Weight: v.Weight, Clicks: v.Clicks})
+	}
+	for _, s := range url.Schedules {
+		record.Schedule = append(record.Schedule, ScheduleRecord{LongURL: s.LongURL, StartsAt: s.StartsAt})
+	}
+	return record
+}
+
+// ClickRecord is an exported click event
+type ClickRecord struct {
+	Domain    string    `json:"domain,omitempty"`
+	ShortURL  string    `json:"short_url"`
+	VariantID *uint     `json:"variant_id,omitempty"`
+	Referrer  string    `json:"referrer,omitempty"`
+	UserAgent string    `json:"user_agent,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// clickCSVHeader lists the columns of a CSV click export
+var clickCSVHeader = []string{"short_url", "variant_id", "referrer", "user_agent", "created_at", "domain"}
+
+// csvRow flattens the click event into a CSV row
+func (c ClickRecord) csvRow() []string {
+	variantID := ""
+	if c.VariantID != nil {
+		variantID = strconv.FormatUint(uint64(*c.VariantID), 10)
+	}
+	return []string{c.ShortURL, variantID, c.Referrer, c.UserAgent, formatTime(&c.CreatedAt), c.Domain}
+}
+
+// formatTime formats an optional timestamp as RFC3339
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// ExportLinks streams every short URL created within filter to w
+func ExportLinks(db *gorm.DB, w io.Writer, format string, filter Filter) error {
+	out, err := newWriter(w, format, linkCSVHeader)
+	if err != nil {
+		return err
+	}
+
+	var urls []models.URL
+	query := filter.apply(db.Model(&models.URL{}), "created_at").Preload("Variants").Preload("Schedules")
+	result := query.FindInBatches(&urls, batchSize, func(tx *gorm.DB, batch int) error {
+		for i := range urls {
+			record := newLinkRecord(&urls[i])
+			if err := out.write(record, record.csvRow()); err != nil {
+				return err
+			}
+		}
+		return out.flush()
+	})
+	if result.Error != nil {
+		return result.Error
+	}
+	return out.close()
+}
+
+// ExportClicks streams every click event recorded within filter to w
+func ExportClicks(db *gorm.DB, w io.Writer, format string, filter Filter) error {
+	out, err := newWriter(w, format, clickCSVHeader)
+	if err != nil {
+		return err
+	}
+
+	var events []models.ClickEvent
+	query := filter.apply(db.Model(&models.ClickEvent{}), "created_at")
+	result := query.FindInBatches(&events, batchSize, func(tx *gorm.DB, batch int) error {
+		for _, e := range events {
+			record := ClickRecord{
+				Domain:    e.Domain,
+				ShortURL:  e.ShortURL,
+				VariantID: e.VariantID,
+				Referrer:  e.Referrer,
+				UserAgent: e.UserAgent,
+				CreatedAt: e.CreatedAt,
+			}
+			if err := out.write(record, record.csvRow()); err != nil {
+				return err
+			}
+		}
+		return out.flush()
+	})
+	if result.Error != nil {
+		return result.Error
+	}
+	return out.close()
+}
+
+// writer encodes records in one of the export formats
+type writer struct {
+	format  string
+	w       io.Writer
+	csv     *csv.Writer
+	encoder *json.Encoder
+	count   int
+}
+
+// newWriter starts an export in the given format, writing the CSV header or opening the JSON array
+func newWriter(w io.Writer, format string, header []string) (*writer, error) {
+	out := &writer{format: format, w: w}
+	switch format {
+	case FormatCSV:
+		out.csv = csv.NewWriter(w)
+		if err := out.csv.Write(header); err != nil {
+			return nil, err
+		}
+	case FormatJSON:
+		if _, err := io.WriteString(w, "["); err != nil {
+			return nil, err
+		}
+		out.encoder = json.NewEncoder(w)
+	case FormatNDJSON:
+		out.encoder = json.NewEncoder(w)
+	default:
+		return nil, fmt.Errorf("unsupported format %q", format)
+	}
+	return out, nil
+}
+
+// write encodes one record, using row for CSV exports
+func (out *writer) write(record interface{}, row []string) error {
+	defer func() { out.count++ }()
+
+	switch out.format {
+	case FormatCSV:
+		return out.csv.Write(row)
+	case FormatJSON:
+		if out.count > 0 {
+			if _, err := io.WriteString(out.w, ","); err != nil {
+				return err
+			}
+		}
+	}
+	return out.encoder.Encode(record)
+}
+
+// flush pushes buffered CSV rows to the underlying writer
+func (out *writer) flush() error {
+	if out.csv != nil {
+		out.csv.Flush()
+		return out.csv.Error()
+	}
+	return nil
+}
+
+// close finishes the export, closing the JSON array
+func (out *writer) close() error {
+	if out.format == FormatJSON {
+		_, err := io.WriteString(out.w, "]\n")
+		return err
+	}
+	return out.flush()
+}
@@ -0,0 +1,73 @@
+package exporter
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testLinks = []LinkRecord{
+	{
+		ShortURL:  "abc",
+		LongURL:   "https://example.com/a",
+		CreatedAt: time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+		Clicks:    3,
+		Variants:  []VariantRecord{{LongURL: "https://example.com/b", Weight: 1, Clicks: 2}},
+	},
+	{Domain: "go.example.com", ShortURL: "def", LongURL: "https://example.com/d", CreatedAt: time.Date(2025, 2, 3, 4, 5, 6, 0, time.UTC)},
+}
+
+// writeLinks exports testLinks in format
+func writeLinks(t *testing.T, format string) string {
+	var buf bytes.Buffer
+	out, err := newWriter(&buf, format, linkCSVHeader)
+	assert.NoError(t, err)
+	for _, link := range testLinks {
+		assert.NoError(t, out.write(link, link.csvRow()))
+	}
+	assert.NoError(t, out.close())
+	return buf.String()
+}
+
+func TestWriteJSON(t *testing.T) {
+	var links []LinkRecord
+	assert.NoError(t, json.Unmarshal([]byte(writeLinks(t, FormatJSON)), &links))
+	assert.Equal(t, testLinks, links)
+}
+
+func TestWriteEmptyJSON(t *testing.T) {
+	var buf bytes.Buffer
+	out, err := newWriter(&buf, FormatJSON, linkCSVHeader)
+	assert.NoError(t, err)
+	assert.NoError(t, out.close())
+	assert.Equal(t, "[]\n", buf.String())
+}
+
+func TestWriteNDJSON(t *testing.T) {
+	lines := strings.Split(strings.TrimSpace(writeLinks(t, FormatNDJSON)), "\n")
+	assert.Len(t, lines, 2)
+
+	var link LinkRecord
+	assert.NoError(t, json.Unmarshal([]byte(lines[1]), &link))
+	assert.Equal(t, "def", link.ShortURL)
+}
+
+func TestWriteCSV(t *testing.T) {
+	rows, err := csv.NewReader(strings.NewReader(writeLinks(t, FormatCSV))).ReadAll()
+	assert.NoError(t, err)
+	assert.Len(t, rows, 3)
+	assert.Equal(t, linkCSVHeader, rows[0])
+	assert.Equal(t, []string{"abc", "https://example.com/a", "2025-01-02T03:04:05Z", "", "", "3", "false", "", "", "", "", "", ""}, rows[1])
+	assert.Equal(t, "go.example.com", rows[2][len(rows[2])-1])
+}
+
+func TestUnsupportedFormat(t *testing.T) {
+	_, err := newWriter(&bytes.Buffer{}, "xml", linkCSVHeader)
+	assert.Error(t, err)
+	assert.False(t, ValidFormat("xml"))
+}
@@ -0,0 +1,254 @@
+package importer
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Record is a link read from another shortener's export
+type Record struct {
+	Line      int // Line or item number in the source file, for reporting
+	ShortURL  string
+	LongURL   string
+	Title     string
+	CreatedAt time.Time
+	Clicks    int
+}
+
+// Supported import formats
+const (
+	FormatYOURLS = "yourls"
+	FormatShlink = "shlink"
+	FormatBitly  = "bitly"
+	FormatKutt   = "kutt"
+)
+
+// Formats lists the supported import formats
+var Formats = []string{FormatYOURLS, FormatShlink, FormatBitly, FormatKutt}
+
+// csvColumns maps each record field to the header names a CSV export may use for it
+type csvColumns struct {
+	short   []string
+	long    []string
+	title   []string
+	created []string
+	clicks  []string
+}
+
+// csvFormats describes the CSV exports of each supported shortener. Header names are
+// compared after lower-casing and removing everything but letters and digits.
+var csvFormats = map[string]csvColumns{
+	FormatYOURLS: {
+		short:   []string{"keyword"},
+		long:    []string{"url", "longurl"},
+		title:   []string{"title"},
+		created: []string{"timestamp", "date"},
+		clicks:  []string{"clicks"},
+	},
+	FormatShlink: {
+		short:   []string{"shortcode", "shorturl"},
+		long:    []string{"longurl", "originalurl"},
+		title:   []string{"title"},
+		created: []string{"datecreated", "createdat", "created"},
+		clicks:  []string{"visits", "visitscount", "visitssummarytotal"},
+	},
+	FormatBitly: {
+		short:   []string{"customlink", "bitlink", "shortlink", "link", "id"},
+		long:    []string{"longurl", "destinationurl", "url"},
+		title:   []string{"title"},
+		created: []string{"createdat", "created", "createddate", "datecreated"},
+		clicks:  []string{"clicks", "totalclicks", "engagements"},
+	},
+}
+
+// dateLayouts are the timestamp formats found in supported exports
+var dateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04:05 -0700 MST",
+	"2006-01-02 15:04:05-07:00",
+	"2006-01-02",
+	"01/02/2006 15:04",
+	"01/02/2006",
+}
+
+// Parse reads the records of an export in the given format
+func Parse(format string, r io.Reader) ([]Record, error) {
+	if format == FormatKutt {
+		return parseKutt(r)
+	}
+	columns, ok := csvFormats[format]
+	if !ok {
+		return nil, fmt.Errorf("unsupported format %q", format)
+	}
+	return parseCSV(r, columns)
+}
+
+// normalizeHeader lower-cases a header and strips everything but letters and digits
+func normalizeHeader(h string) string {
+	var b strings.Builder
+	for _, c := range strings.ToLower(h) {
+		if (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') {
+			b.WriteRune(c)
+		}
+	}
+	return b.String()
+}
+
+// parseCSV reads a CSV export whose first row is a header
+func parseCSV(r io.Reader, columns csvColumns) ([]Record, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read header: %w", err)
+	}
+	index := make(map[string]int, len(header))
+	for i, h := range header {
+		index[normalizeHeader(strings.TrimPrefix(h, "\ufeff"))] = i
+	}
+
+	find := func(names []string) int {
+		for _, name := range names {
+			if i, ok := index[name]; ok {
+				return i
+			}
+		}
+		return -1
+	}
+	shortCol, longCol := find(columns.short), find(columns.long)
+	if shortCol < 0 || longCol < 0 {
+		return nil, errors.New("export is missing the short URL or long URL column")
+	}
+	titleCol, createdCol, clicksCol := find(columns.title), find(columns.created), find(columns.clicks)
+
+	field := func(row []string, col int) string {
+		if col < 0 || col >= len(row) {
+			return ""
+		}
+		return strings.TrimSpace(row[col])
+	}
+
+	var records []Record
+	for line := 2; ; line++ {
+		row, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		records = append(records, Record{
+			Line:      line,
+			ShortURL:  slug(field(row, shortCol)),
+			LongURL:   field(row, longCol),
+			Title:     field(row, titleCol),
+			CreatedAt: parseDate(field(row, createdCol)),
+			Clicks:    parseClicks(field(row, clicksCol)),
+		})
+	}
+	return records, nil
+}
+
+// kuttLink is a link as returned by the Kutt API and its JSON exports
+type kuttLink struct {
+	Address     string          `json:"address"`
+	Link        string          `json:"link"`
+	Target      string          `json:"target"`
+	Description string          `json:"description"`
+	VisitCount  json.RawMessage `json:"visit_count"`
+	CreatedAt   string          `json:"created_at"`
+}
+
+// parseKutt reads a Kutt JSON export, either a bare array or the API's {"data": [...]} envelope
+func parseKutt(r io.Reader) ([]Record, error) {
+	raw, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var links []kuttLink
+	if err := json.Unmarshal(raw, &links); err != nil {
+		var envelope struct {
+			Data []kuttLink `json:"data"`
+		}
+		if err := json.Unmarshal(raw, &envelope); err != nil {
+			return nil, fmt.Errorf("invalid Kutt export: %w", err)
+		}
+		links = envelope.Data
+	}
+
+	records := make([]Record, 0, len(links))
+	for i, link := range links {
+		short := link.Address
+		if short == "" {
+			short = slug(link.Link)
+		}
+		records = append(records, Record{
+			Line:      i + 1,
+			ShortURL:  short,
+			LongURL:   strings.TrimSpace(link.Target),
+			Title:     link.Description,
+			CreatedAt: parseDate(link.CreatedAt),
+			Clicks:    parseClicks(strings.Trim(string(link.VisitCount), `"`)),
+		})
+	}
+	return records, nil
+}
+
+// slug extracts the short code from a full short link such as https://bit.ly/abc
+func slug(s string) string {
+	s = strings.TrimSpace(s)
+	if !strings.Contains(s, "/") {
+		return s
+	}
+	if !strings.Contains(s, "://") {
+		s = "https://" + s
+	}
+	u, err := url.Parse(s)
+	if err != nil {
+		return s
+	}
+	path := strings.Trim(u.Path, "/")
+	if i := strings.LastIndex(path, "/"); i >= 0 {
+		path = path[i+1:]
+	}
+	return path
+}
+
+// parseDate parses a timestamp in any of the known layouts, returning the zero time on failure
+func parseDate(s string) time.Time {
+	if s == "" {
+		return time.Time{}
+	}
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t
+		}
+	}
+	// Unix timestamps
+	if secs, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.Unix(secs, 0).UTC()
+	}
+	return time.Time{}
+}
+
+// parseClicks parses a click count, treating anything unparseable as zero
+func parseClicks(s string) int {
+	n, err := strconv.Atoi(strings.ReplaceAll(s, ",", ""))
+	if err != nil || n < 0 {
+		return 0
+	}
+	return n
+}
@@ -0,0 +1,314 @@
+package importer
+
+import (
+	"GoShort/internal/models"
+	"GoShort/internal/utils"
+	"errors"
+	"fmt"
+	"maps"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// Strategy decides what happens when an imported short URL already exists
+type Strategy string
+
+// Supported conflict strategies
+const (
+	StrategySkip      Strategy = "skip"      // Keep the existing link and drop the imported one
+	StrategyOverwrite Strategy = "overwrite" // Replace the existing link's destination and stats
+	StrategyRename    Strategy = "rename"    // Import under a new short URL with a numeric suffix
+)
+
+// ParseStrategy validates a conflict strategy name
+func ParseStrategy(s string) (Strategy, error) {
+	switch Strategy(s) {
+	case StrategySkip, StrategyOverwrite, StrategyRename:
+		return Strategy(s), nil
+	case "":
+		return StrategySkip, nil
+	}
+	return "", fmt.Errorf("unsupported conflict strategy %q", s)
+}
+
+// Actions reported for each imported record
+const (
+	ActionCreate    = "create"
+	ActionOverwrite = "overwrite"
+	ActionRename    = "rename"
+	ActionSkip      = "skip"
+	ActionFail      = "fail"
+)
+
+// batchSize is the number of lookups and inserts issued per query
+const batchSize = 100
+
+// maxRenameAttempts bounds the search for a free suffix when renaming
+const maxRenameAttempts = 100
+
+// Options controls an import run
+type Options struct {
+	Strategy Strategy
+	DryRun   bool
+	Domain   string // Custom domain the links are imported into, empty for the default namespace
+}
+
+// ItemReport describes what happened to one imported record
+type ItemReport struct {
+	Line        int    `json:"line"`
+	ShortURL    string `json:"short_url"`
+	NewShortURL string `json:"new_short_url,omitempty"`
+	Action      string `json:"action"`
+	Error       string `json:"error,omitempty"`
+}
+
+// Report summarises an import run
+type Report struct {
+	DryRun      bool         `json:"dry_run"`
+	Total       int          `json:"total"`
+	Created     int          `json:"created"`
+	Overwritten int          `json:"overwritten"`
+	Renamed     int          `json:"renamed"`
+	Skipped     int          `json:"skipped"`
+	Failed      int          `json:"failed"`
+	Items       []ItemReport `json:"items"`
+}
+
+// Written returns the short URLs created or changed by the import, none for a dry run
+func (r *Report) Written() []string {
+	if r.DryRun {
+		return nil
+	}
+	var written []string
+	for _, item := range r.Items {
+		switch item.Action {
+		case ActionCreate, ActionOverwrite:
+			written = append(written, item.ShortURL)
+		case ActionRename:
+			written = append(written, item.NewShortURL)
+		}
+	}
+	return written
+}
+
+// Import stores records using the given conflict strategy. With DryRun set the
+// report describes what would happen without writing anything.
+func Import(db *gorm.DB, records []Record, opts Options) (*Report, error) {
+	existing, err := existingShortURLs(db, opts.Domain, records)
+	if err != nil {
+		return nil, err
+	}
+
+	items := plan(records, existing, opts.Strategy)
+	report := summarize(items, opts.DryRun)
+	if opts.DryRun {
+		return report, nil
+	}
+
+	// Renames retried while importing must avoid the short URLs planned for other records too
+	taken := maps.Clone(existing)
+	for _, item := range items {
+		switch item.Action {
+		case ActionCreate, ActionOverwrite:
+			taken[item.ShortURL] = true
+		case ActionRename:
+			taken[item.NewShortURL] = true
+		}
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		var creates []models.URL
+		for i, item := range items {
+			record := records[i]
+			url := models.URL{
+				Domain:    opts.Domain,
+				ShortURL:  item.ShortURL,
+				LongURL:   record.LongURL,
+				Title:     record.Title,
+				CreatedAt: record.CreatedAt,
+				Clicks:    record.Clicks,
+			}
+			switch item.Action {
+			case ActionCreate:
+				creates = append(creates, url)
+			case ActionRename:
+				if err := createRenamed(tx, url, &items[i], taken); err != nil {
+					return fmt.Errorf("failed to import %s: %w", item.ShortURL, err)
+				}
+			case ActionOverwrite:
+				updates := map[string]interface{}{
+					"long_url": record.LongURL,
+					"title":    record.Title,
+					"clicks":   record.Clicks,
+				}
+				if !record.CreatedAt.IsZero() {
+					updates["created_at"] = record.CreatedAt
+				}
+				if err := tx.Model(&models.URL{}).Where("domain = ? AND short_url = ?", opts.Domain, item.ShortURL).Updates(updates).Error; err != nil {
+					return fmt.Errorf("failed to overwrite %s: %w", item.ShortURL, err)
+				}
+			}
+		}
+		if len(creates) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(creates, batchSize).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	// Renamed items may have changed while importing
+	return summarize(items, false), nil
+}
+
+// createRenamed inserts a renamed record under a savepoint, moving on to the next free suffix
+// when a concurrent writer took the planned one in the meantime. taken holds the short URLs
+// known to exist and is updated with those found taken.
+func createRenamed(tx *gorm.DB, url models.URL, item *ItemReport, taken map[string]bool) error {
+	url.ShortURL = item.NewShortURL
+	for url.ShortURL != "" {
+		candidate := url
+		err := tx.Transaction(func(tx *gorm.DB) error {
+			return tx.Create(&candidate).Error
+		})
+		if err == nil {
+			item.NewShortURL = url.ShortURL
+			return nil
+		}
+		if !errors.Is(err, gorm.ErrDuplicatedKey) {
+			return err
+		}
+		taken[url.ShortURL] = true
+		url.ShortURL = freeSuffix(item.ShortURL, taken)
+	}
+	item.Action, item.NewShortURL, item.Error = ActionFail, "", "no free short URL to rename to"
+	return nil
+}
+
+// existingShortURLs returns which of the records' short URLs, and renamed candidates, are already taken in domain
+func existingShortURLs(db *gorm.DB, domain string, records []Record) (map[string]bool, error) {
+	existing := make(map[string]bool)
+
+	var candidates []string
+	for _, record := range records {
+		candidates = append(candidates, record.ShortURL)
+	}
+
+	for start := 0; start < len(candidates); start += batchSize {
+		end := start + batchSize
+		if end > len(candidates) {
+			end = len(candidates)
+		}
+		var taken []string
+		if err := db.Model(&models.URL{}).Where("domain = ? AND short_url IN ?", domain, candidates[start:end]).Pluck("short_url", &taken).Error; err != nil {
+			return nil, fmt.Errorf("failed to look up existing links: %w", err)
+		}
+		for _, shortURL := range taken {
+			existing[shortURL] = true
+		}
+	}
+
+	// Renamed links use suffixes of existing ones, so load those as well, a batch at a time
+	conflicts := make([]string, 0, len(existing))
+	for shortURL := range existing {
+		conflicts = append(conflicts, shortURL)
+	}
+	for start := 0; start < len(conflicts); start += batchSize {
+		end := min(start+batchSize, len(conflicts))
+		suffixes := db.Where("short_url LIKE ? ESCAPE '\\'", escapeLike(conflicts[start])+"-%")
+		for _, shortURL := range conflicts[start+1 : end] {
+			suffixes = suffixes.Or("short_url LIKE ? ESCAPE '\\'", escapeLike(shortURL)+"-%")
+		}
+		var taken []string
+		if err := db.Model(&models.URL{}).Where("domain = ?", domain).Where(suffixes).Pluck("short_url", &taken).Error; err != nil {
+			return nil, fmt.Errorf("failed to look up existing links: %w", err)
+		}
+		for _, t := range taken {
+			existing[t] = true
+		}
+	}
+	return existing, nil
+}
+
+// escapeLike escapes the wildcards of a LIKE pattern, using a backslash as the escape character
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// plan decides the action for each record given the short URLs that already exist.
+// Records repeating a short URL earlier in the same import count as conflicts too.
+func plan(records []Record, existing map[string]bool, strategy Strategy) []ItemReport {
+	taken := make(map[string]bool, len(existing))
+	for shortURL := range existing {
+		taken[shortURL] = true
+	}
+	imported := make(map[string]bool, len(records))
+
+	items := make([]ItemReport, len(records))
+	for i, record := range records {
+		item := ItemReport{Line: record.Line, ShortURL: record.ShortURL}
+
+		switch {
+		case !utils.ValidateCustomShortURL(record.ShortURL):
+			item.Action, item.Error = ActionFail, "invalid short URL"
+		case !utils.ValidateURL(record.LongURL):
+			item.Action, item.Error = ActionFail, "invalid long URL"
+		case !taken[record.ShortURL]:
+			item.Action = ActionCreate
+		case strategy == StrategyOverwrite && !imported[record.ShortURL]:
+			item.Action = ActionOverwrite
+		case strategy == StrategyRename:
+			item.Action = ActionRename
+			item.NewShortURL = freeSuffix(record.ShortURL, taken)
+			if item.NewShortURL == "" {
+				item.Action, item.Error = ActionFail, "no free short URL to rename to"
+			}
+		default:
+			item.Action = ActionSkip
+		}
+
+		switch item.Action {
+		case ActionCreate, ActionOverwrite:
+			taken[record.ShortURL] = true
+			imported[record.ShortURL] = true
+		case ActionRename:
+			taken[item.NewShortURL] = true
+			imported[item.NewShortURL] = true
+		}
+		items[i] = item
+	}
+	return items
+}
+
+// freeSuffix finds the first shortURL-N that is not taken
+func freeSuffix(shortURL string, taken map[string]bool) string {
+	for n := 2; n < maxRenameAttempts+2; n++ {
+		candidate := shortURL + "-" + strconv.Itoa(n)
+		if !taken[candidate] {
+			return candidate
+		}
+	}
+	return ""
+}
+
+// summarize counts the planned actions
+func summarize(items []ItemReport, dryRun bool) *Report {
+	report := &Report{DryRun: dryRun, Total: len(items), Items: items}
+	for _, item := range items {
+		switch item.Action {
+		case ActionCreate:
+			report.Created++
+		case ActionOverwrite:
+			report.Overwritten++
+		case ActionRename:
+			report.Renamed++
+		case ActionSkip:
+			report.Skipped++
+		case ActionFail:
+			report.Failed++
+		}
+	}
+	return report
+}
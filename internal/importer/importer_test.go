@@ -0,0 +1,172 @@
+package importer
+
+import (
+	"GoShort/internal/models"
+	"GoShort/migrations"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestParseYOURLS(t *testing.T) {
+	export := "keyword,url,title,timestamp,ip,clicks\n" +
+		"abc,https://example.com/a,Example A,2023-04-05 10:11:12,127.0.0.1,17\n" +
+		"def,https://example.com/b,,2023-04-06 00:00:00,127.0.0.1,0\n"
+
+	records, err := Parse(FormatYOURLS, strings.NewReader(export))
+	assert.NoError(t, err)
+	assert.Len(t, records, 2)
+	assert.Equal(t, "abc", records[0].ShortURL)
+	assert.Equal(t, "https://example.com/a", records[0].LongURL)
+	assert.Equal(t, "Example A", records[0].Title)
+	assert.Equal(t, 17, records[0].Clicks)
+	assert.Equal(t, time.Date(2023, 4, 5, 10, 11, 12, 0, time.UTC), records[0].CreatedAt)
+	assert.Equal(t, 3, records[1].Line)
+}
+
+func TestParseShlink(t *testing.T) {
+	export := "createdAt,shortUrl,longUrl,title,tags,visits\n" +
+		"2024-01-02T03:04:05+00:00,https://s.test/promo,https://example.com/promo,Promo,,1234\n"
+
+	records, err := Parse(FormatShlink, strings.NewReader(export))
+	assert.NoError(t, err)
+	assert.Len(t, records, 1)
+	assert.Equal(t, "promo", records[0].ShortURL, "Expected the slug to be extracted from the full short URL")
+	assert.Equal(t, "https://example.com/promo", records[0].LongURL)
+}
+
+func TestParseBitly(t *testing.T) {
+	export := "Title,Bitlink,Long URL,Created At,Clicks\n" +
+		"Docs,bit.ly/3xYz,https://example.com/docs,2022-12-01,\"1,024\"\n"
+
+	records, err := Parse(FormatBitly, strings.NewReader(export))
+	assert.NoError(t, err)
+	assert.Len(t, records, 1)
+	assert.Equal(t, "3xYz", records[0].ShortURL)
+	assert.Equal(t, 1024, records[0].Clicks)
+	assert.Equal(t, time.Date(2022, 12, 1, 0, 0, 0, 0, time.UTC), records[0].CreatedAt)
+}
+
+func TestParseKutt(t *testing.T) {
+	export := `{"data":[{"address":"kt1","target":"https://example.com/k","description":"Kutt link","visit_count":5,"created_at":"2021-07-08T09:10:11.000Z"}]}`
+
+	records, err := Parse(FormatKutt, strings.NewReader(export))
+	assert.NoError(t, err)
+	assert.Len(t, records, 1)
+	assert.Equal(t, "kt1", records[0].ShortURL)
+	assert.Equal(t, "https://example.com/k", records[0].LongURL)
+	assert.Equal(t, 5, records[0].Clicks)
+	assert.Equal(t, 2021, records[0].CreatedAt.Year())
+}
+
+func TestParseErrors(t *testing.T) {
+	_, err := Parse("unknown", strings.NewReader(""))
+	assert.Error(t, err)
+
+	_, err = Parse(FormatYOURLS, strings.NewReader("title,clicks\nfoo,1\n"))
+	assert.Error(t, err, "Expected missing columns to be rejected")
+}
+
+func TestPlan(t *testing.T) {
+	records := []Record{
+		{Line: 1, ShortURL: "new", LongURL: "https://example.com/new"},
+		{Line: 2, ShortURL: "taken", LongURL: "https://example.com/taken"},
+		{Line: 3, ShortURL: "bad slug", LongURL: "https://example.com"},
+		{Line: 4, ShortURL: "new", LongURL: "https://example.com/again"},
+	}
+	existing := map[string]bool{"taken": true, "taken-2": true}
+
+	items := plan(records, existing, StrategySkip)
+	assert.Equal(t, ActionCreate, items[0].Action)
+	assert.Equal(t, ActionSkip, items[1].Action)
+	assert.Equal(t, ActionFail, items[2].Action)
+	assert.Equal(t, ActionSkip, items[3].Action, "Expected a repeated slug to conflict with the earlier record")
+
+	items = plan(records, existing, StrategyOverwrite)
+	assert.Equal(t, ActionOverwrite, items[1].Action)
+	assert.Equal(t, ActionSkip, items[3].Action, "Expected a repeated slug not to overwrite a record from the same import")
+
+	items = plan(records, existing, StrategyRename)
+	assert.Equal(t, ActionRename, items[1].Action)
+	assert.Equal(t, "taken-3", items[1].NewShortURL)
+	assert.Equal(t, ActionRename, items[3].Action)
+	assert.Equal(t, "new-2", items[3].NewShortURL)
+
+	report := summarize(items, true)
+	assert.True(t, report.DryRun)
+	assert.Equal(t, 4, report.Total)
+	assert.Equal(t, 1, report.Created)
+	assert.Equal(t, 2, report.Renamed)
+	assert.Equal(t, 1, report.Failed)
+	assert.Empty(t, report.Written(), "Expected a dry run to write nothing")
+
+	report = summarize(items, false)
+	assert.Equal(t, []string{"new", "taken-3", "new-2"}, report.Written())
+}
+
+func TestParseStrategy(t *testing.T) {
+	strategy, err := ParseStrategy("")
+	assert.NoError(t, err)
+	assert.Equal(t, StrategySkip, strategy)
+
+	_, err = ParseStrategy("merge")
+	assert.Error(t, err)
+}
+
+// newSQLiteDB opens a fresh, migrated SQLite database
+func newSQLiteDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "goshort.db")), &gorm.Config{
+		TranslateError: true,
+		Logger:         logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	_, err = migrations.Up(db)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+	})
+	return db
+}
+
+func TestExistingShortURLsEscapesPatterns(t *testing.T) {
+	db := newSQLiteDB(t)
+	for _, shortURL := range []string{"a_b", "a_b-2", "axb-2", "50%", "50%-2", "500-2"} {
+		require.NoError(t, db.Create(&models.URL{ShortURL: shortURL, LongURL: "https://example.com"}).Error)
+	}
+
+	existing, err := existingShortURLs(db, "", []Record{{ShortURL: "a_b"}, {ShortURL: "50%"}, {ShortURL: "new"}})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]bool{"a_b": true, "a_b-2": true, "50%": true, "50%-2": true}, existing)
+}
+
+func TestImportRetriesTakenRenames(t *testing.T) {
+	db := newSQLiteDB(t)
+	require.NoError(t, db.Create(&models.URL{ShortURL: "promo", LongURL: "https://example.com"}).Error)
+
+	item := ItemReport{ShortURL: "promo", NewShortURL: "promo-2", Action: ActionRename}
+	taken := map[string]bool{"promo": true, "promo-2": true}
+
+	// Another writer takes the planned rename between planning and inserting
+	require.NoError(t, db.Create(&models.URL{ShortURL: "promo-2", LongURL: "https://example.com/other"}).Error)
+
+	url := models.URL{ShortURL: "promo", LongURL: "https://example.com/imported"}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		return createRenamed(tx, url, &item, taken)
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, ActionRename, item.Action)
+	assert.Equal(t, "promo-3", item.NewShortURL)
+
+	var stored models.URL
+	assert.NoError(t, db.Where("short_url = ?", "promo-3").First(&stored).Error)
+	assert.Equal(t, "https://example.com/imported", stored.LongURL)
+}
@@ -0,0 +1,256 @@
+package metadata
+
+import (
+	"GoShort/internal/db"
+	"GoShort/internal/models"
+	"GoShort/internal/store"
+	"GoShort/pkg/logger"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// maxBodyBytes limits how much of a destination page is read
+	maxBodyBytes = 1 << 20
+	// fetchTimeout bounds the whole fetch including redirects
+	fetchTimeout = 10 * time.Second
+	// maxRedirects limits how many redirects are followed
+	maxRedirects = 5
+	// queueSize is the number of pending fetches before new ones are dropped
+	queueSize = 256
+	// maxTitleLength and maxDescriptionLength cap the stored metadata
+	maxTitleLength       = 300
+	maxDescriptionLength = 1000
+)
+
+// ErrBlockedAddress is returned when a destination resolves to a non-public address
+var ErrBlockedAddress = errors.New("destination resolves to a blocked address")
+
+// job is a pending metadata fetch for a stored URL
+type job struct {
+	urlID    uint
+	domain   string
+	shortURL string
+	longURL  string
+}
+
+// Fetcher retrieves titles, descriptions and favicons of destination pages in the background
+type Fetcher struct {
+	client       *http.Client
+	cache        store.Invalidator // Drops cached links whose metadata changed; may be nil
+	queue        chan job
+	ctx          context.Context // Cancelled by Close to abandon queued and running fetches
+	cancel       context.CancelFunc
+	wg           sync.WaitGroup
+	closeOnce    sync.Once
+	allowPrivate bool // Only used by tests to reach local servers
+}
+
+// NewFetcher creates a Fetcher and starts the given number of background workers. Links
+// whose metadata is stored are invalidated in cache when it is not nil.
+func NewFetcher(workers int, cache store.Invalidator) *Fetcher {
+	f := &Fetcher{cache: cache, queue: make(chan job, queueSize)}
+	f.ctx, f.cancel = context.WithCancel(context.Background())
+
+	dialer := &net.Dialer{
+		Timeout: 5 * time.Second,
+		Control: f.checkAddress,
+	}
+	f.client = &http.Client{
+		Timeout: fetchTimeout,
+		Transport: &http.Transport{
+			Proxy:                 nil, // A proxy would bypass the address checks
+			DialContext:           dialer.DialContext,
+			TLSHandshakeTimeout:   5 * time.Second,
+			ResponseHeaderTimeout: 5 * time.Second,
+			MaxIdleConns:          10,
+			IdleConnTimeout:       30 * time.Second,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxRedirects {
+				return errors.New("too many redirects")
+			}
+			if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+				return errors.New("unsupported redirect scheme")
+			}
+			return nil
+		},
+	}
+
+	for i := 0; i < workers; i++ {
+		f.wg.Add(1)
+		go f.work()
+	}
+	return f
+}
+
+// Enqueue schedules a metadata fetch for the stored URL. Fetches are dropped when the queue is full.
+func (f *Fetcher) Enqueue(url *models.URL) {
+	select {
+	case f.queue <- job{urlID: url.ID, domain: url.Domain, shortURL: url.ShortURL, longURL: url.LongURL}:
+	default:
+		logger.Warn("Metadata queue full, skipping fetch", logger.Fields{"url_id": url.ID})
+	}
+}
+
+// Close stops accepting new fetches, drops the queued ones and cancels those running, then
+// waits for the workers to stop until ctx is done
+func (f *Fetcher) Close(ctx context.Context) error {
+	f.closeOnce.Do(func() {
+		close(f.queue)
+		f.cancel()
+	})
+
+	stopped := make(chan struct{})
+	go func() {
+		f.wg.Wait()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// work processes queued fetches until the queue is closed
+func (f *Fetcher) work() {
+	defer f.wg.Done()
+
+	for j := range f.queue {
+		if f.ctx.Err() != nil {
+			// Closing: drain the queue without fetching
+			continue
+		}
+		ctx, cancel := context.WithTimeout(f.ctx, fetchTimeout)
+		page, err := f.Fetch(ctx, j.longURL)
+		cancel()
+		if f.ctx.Err() != nil {
+			// Cancelled by Close, which is no reason to mark the link as fetched
+			continue
+		}
+
+		now := time.Now()
+		updates := map[string]interface{}{"metadata_fetched_at": now}
+		if err != nil {
+			// The error repeats the destination, whose query may hold credentials
+			var urlErr *url.Error
+			if errors.As(err, &urlErr) {
+				err = urlErr.Err
+			}
+			logger.Warn("Failed to fetch metadata", logger.Fields{"url_id": j.urlID, "url": logger.RedactURL(j.longURL), "error": err})
+		} else {
+			updates["title"] = page.Title
+			updates["description"] = page.Description
+			updates["favicon_url"] = page.FaviconURL
+		}
+
+		if err := db.DB.Model(&models.URL{}).Where("id = ?", j.urlID).Updates(updates).Error; err != nil {
+			logger.Error("Failed to store metadata", logger.Fields{"url_id": j.urlID, "error": err})
+			continue
+		}
+		// Cached copies of the link would otherwise keep showing no title until they expire
+		if f.cache != nil {
+			f.cache.Invalidate(context.Background(), j.domain, j.shortURL)
+		}
+	}
+}
+
+// Fetch retrieves and parses the metadata of the page at rawURL
+func (f *Fetcher) Fetch(ctx context.Context, rawURL string) (*Page, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+		return nil, errors.New("unsupported scheme")
+	}
+	req.Header.Set("User-Agent", "GoShort-Metadata/1.0")
+	req.Header.Set("Accept", "text/html,application/xhtml+xml")
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "" && !strings.Contains(ct, "html") {
+		return nil, fmt.Errorf("unsupported content type %q", ct)
+	}
+
+	page := Parse(io.LimitReader(resp.Body, maxBodyBytes), resp.Request.URL)
+	page.Title = truncate(page.Title, maxTitleLength)
+	page.Description = truncate(page.Description, maxDescriptionLength)
+	return page, nil
+}
+
+// checkAddress rejects connections to loopback, private and other non-public addresses
+func (f *Fetcher) checkAddress(network, address string, _ syscall.RawConn) error {
+	if f.allowPrivate {
+		return nil
+	}
+
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || !isPublicIP(ip) {
+		return ErrBlockedAddress
+	}
+	return nil
+}
+
+// sharedAddressSpace is the carrier-grade NAT range, which is not publicly routable
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// isPublicIP checks if ip is a globally routable unicast address
+func isPublicIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return false
+	}
+	if ip4 := ip.To4(); ip4 != nil && sharedAddressSpace.Contains(ip4) {
+		return false
+	}
+	return true
+}
+
+// truncate shortens s to at most n bytes without splitting a UTF-8 sequence
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+// resolveReference resolves href relative to base, accepting only http(s) results
+func resolveReference(base *url.URL, href string) string {
+	ref, err := url.Parse(strings.TrimSpace(href))
+	if err != nil {
+		return ""
+	}
+	resolved := base.ResolveReference(ref)
+	if resolved.Scheme != "http" && resolved.Scheme != "https" {
+		return ""
+	}
+	return resolved.String()
+}
@@ -0,0 +1,169 @@
+package metadata
+
+import (
+	"GoShort/internal/db"
+	"GoShort/internal/models"
+	"GoShort/migrations"
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestParse(t *testing.T) {
+	base, _ := url.Parse("https://example.com/blog/post")
+	doc := `<!DOCTYPE html><html><head>
+		<title>  Hello
+		World </title>
+		<meta property="og:description" content="OpenGraph description">
+		<meta name="Description" content="Plain description">
+		<link rel="shortcut icon" href="/static/icon.png">
+		</head><body><title>Ignored</title></body></html>`
+
+	page := Parse(strings.NewReader(doc), base)
+	assert.Equal(t, "Hello World", page.Title)
+	assert.Equal(t, "Plain description", page.Description)
+	assert.Equal(t, "https://example.com/static/icon.png", page.FaviconURL)
+}
+
+func TestParseFallbacks(t *testing.T) {
+	base, _ := url.Parse("https://example.com/page")
+	doc := `<html><head><meta property="og:description" content="OpenGraph description"></head></html>`
+
+	page := Parse(strings.NewReader(doc), base)
+	assert.Empty(t, page.Title)
+	assert.Equal(t, "OpenGraph description", page.Description)
+	assert.Equal(t, "https://example.com/favicon.ico", page.FaviconURL)
+}
+
+func TestFetch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(`<html><head><title>Local page</title></head></html>`))
+	}))
+	defer server.Close()
+
+	f := NewFetcher(0, nil)
+	f.allowPrivate = true
+
+	page, err := f.Fetch(context.Background(), server.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, "Local page", page.Title)
+}
+
+// invalidations records the links a Fetcher invalidates
+type invalidations []string
+
+func (i *invalidations) Invalidate(_ context.Context, domain, shortURL string) {
+	*i = append(*i, domain+"/"+shortURL)
+}
+
+func TestFetcherInvalidatesStoredLinks(t *testing.T) {
+	conn, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "goshort.db")), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+	_, err = migrations.Up(conn)
+	require.NoError(t, err)
+	previous := db.DB
+	db.DB = conn
+	t.Cleanup(func() { db.DB = previous })
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(`<html><head><title>Local page</title></head></html>`))
+	}))
+	defer server.Close()
+
+	link := models.URL{Domain: "brand.example", ShortURL: "promo", LongURL: server.URL}
+	require.NoError(t, conn.Create(&link).Error)
+
+	var cache invalidations
+	f := NewFetcher(1, &cache)
+	f.allowPrivate = true
+	f.Enqueue(&link)
+
+	// Close cancels fetches, so wait for this one to be stored first
+	var stored models.URL
+	require.Eventually(t, func() bool {
+		return conn.First(&stored, link.ID).Error == nil && stored.MetadataFetchedAt != nil
+	}, 5*time.Second, 10*time.Millisecond)
+	require.NoError(t, f.Close(context.Background()))
+	assert.Equal(t, "Local page", stored.Title)
+	assert.Equal(t, invalidations{"brand.example/promo"}, cache)
+}
+
+func TestFetcherCloseCancelsFetches(t *testing.T) {
+	started := make(chan struct{}, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	f := NewFetcher(1, nil)
+	f.allowPrivate = true
+	for i := 1; i <= 3; i++ {
+		f.Enqueue(&models.URL{ID: uint(i), LongURL: server.URL})
+	}
+	<-started
+
+	// The running fetch is cancelled and the queued ones are dropped, well before fetchTimeout
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	assert.NoError(t, f.Close(ctx))
+	assert.Empty(t, started, "Queued fetches must not start once closing")
+}
+
+func TestFetchBlocksPrivateAddresses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("Expected the request to be blocked before reaching the server")
+	}))
+	defer server.Close()
+
+	f := NewFetcher(0, nil)
+
+	_, err := f.Fetch(context.Background(), server.URL)
+	assert.True(t, errors.Is(err, ErrBlockedAddress), "Expected loopback destination to be blocked, got %v", err)
+}
+
+func TestFetchRejectsNonHTML(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Write([]byte("binary"))
+	}))
+	defer server.Close()
+
+	f := NewFetcher(0, nil)
+	f.allowPrivate = true
+
+	_, err := f.Fetch(context.Background(), server.URL)
+	assert.Error(t, err)
+}
+
+func TestIsPublicIP(t *testing.T) {
+	assert.True(t, isPublicIP(net.ParseIP("93.184.216.34")))
+	assert.True(t, isPublicIP(net.ParseIP("2606:2800:220:1:248:1893:25c8:1946")))
+	assert.False(t, isPublicIP(net.ParseIP("127.0.0.1")))
+	assert.False(t, isPublicIP(net.ParseIP("10.0.0.1")))
+	assert.False(t, isPublicIP(net.ParseIP("169.254.169.254")))
+	assert.False(t, isPublicIP(net.ParseIP("100.64.0.1")))
+	assert.False(t, isPublicIP(net.ParseIP("::1")))
+	assert.False(t, isPublicIP(net.ParseIP("fd00::1")))
+}
+
+func TestTruncate(t *testing.T) {
+	assert.Equal(t, "abc", truncate("abc", 5))
+	assert.Equal(t, "ab", truncate("abcdef", 2))
+	assert.Equal(t, "a", truncate("aé", 2), "Expected multi-byte rune not to be split")
+}
@@ -0,0 +1,105 @@
+package metadata
+
+import (
+	"io"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Page holds the metadata extracted from a destination page
+type Page struct {
+	Title       string
+	Description string
+	FaviconURL  string
+}
+
+// Parse extracts the title, description and favicon from the head of an HTML document.
+// Relative favicon links are resolved against base, falling back to /favicon.ico.
+func Parse(r io.Reader, base *url.URL) *Page {
+	page := &Page{}
+	var ogDescription string
+	inTitle := false
+
+	z := html.NewTokenizer(r)
+	for {
+		tt := z.Next()
+		switch tt {
+		case html.ErrorToken:
+			return finish(page, ogDescription, base)
+
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := z.TagName()
+			switch string(name) {
+			case "title":
+				inTitle = tt == html.StartTagToken && page.Title == ""
+			case "meta":
+				attrs := attributes(z, hasAttr)
+				content := strings.TrimSpace(attrs["content"])
+				switch {
+				case strings.EqualFold(attrs["name"], "description"):
+					page.Description = content
+				case strings.EqualFold(attrs["property"], "og:description"):
+					ogDescription = content
+				}
+			case "link":
+				attrs := attributes(z, hasAttr)
+				if page.FaviconURL == "" && isIconRel(attrs["rel"]) && attrs["href"] != "" {
+					page.FaviconURL = resolveReference(base, attrs["href"])
+				}
+			case "body":
+				// Everything we need lives in the head
+				return finish(page, ogDescription, base)
+			}
+
+		case html.TextToken:
+			if inTitle {
+				page.Title += string(z.Text())
+			}
+
+		case html.EndTagToken:
+			name, _ := z.TagName()
+			switch string(name) {
+			case "title":
+				inTitle = false
+			case "head":
+				return finish(page, ogDescription, base)
+			}
+		}
+	}
+}
+
+// finish applies fallbacks and normalises whitespace once parsing stops
+func finish(page *Page, ogDescription string, base *url.URL) *Page {
+	page.Title = strings.Join(strings.Fields(page.Title), " ")
+	if page.Description == "" {
+		page.Description = ogDescription
+	}
+	page.Description = strings.Join(strings.Fields(page.Description), " ")
+	if page.FaviconURL == "" {
+		page.FaviconURL = resolveReference(base, "/favicon.ico")
+	}
+	return page
+}
+
+// attributes collects the attributes of the current tag with lower-cased keys
+func attributes(z *html.Tokenizer, hasAttr bool) map[string]string {
+	attrs := make(map[string]string)
+	for hasAttr {
+		var key, val []byte
+		key, val, hasAttr = z.TagAttr()
+		attrs[strings.ToLower(string(key))] = string(val)
+	}
+	return attrs
+}
+
+// isIconRel checks if a link rel attribute refers to a favicon
+func isIconRel(rel string) bool {
+	for _, token := range strings.Fields(strings.ToLower(rel)) {
+		if token == "icon" {
+			return true
+		}
+	}
+	return false
+}
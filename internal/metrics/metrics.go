@@ -0,0 +1,95 @@
+package metrics
+
+import (
+	"GoShort/internal/analytics"
+	"GoShort/internal/store"
+	"database/sql"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// namespace prefixes the name of every GoShort metric
+const namespace = "goshort"
+
+// Outcomes of a redirect request, counted by Redirects
+const (
+	RedirectFound    = "found"     // Redirected to the destination
+	RedirectPreview  = "preview"   // Showed the preview page
+	RedirectCard     = "card"      // Served the social card to a crawler
+	RedirectNotFound = "not_found" // Unknown short URL
+	RedirectFallback = "fallback"  // Unknown short URL sent to the domain's fallback page
+	RedirectExpired  = "expired"   // The link has expired
+	RedirectInactive = "inactive"  // The link is not active yet
+	RedirectError    = "error"     // The link could not be loaded
+)
+
+// ShortenSuccess is the shorten result counted for links created
+const ShortenSuccess = "success"
+
+var (
+	// HTTPRequests counts the HTTP requests served by route template, method and status code
+	HTTPRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests served, by route, method and status code.",
+	}, []string{"route", "method", "status"})
+
+	// HTTPDuration observes how long HTTP requests take by route template and method
+	HTTPDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Time taken to serve HTTP requests, by route and method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"route", "method"})
+
+	// Redirects counts the outcome of short URL visits
+	Redirects = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "redirects_total",
+		Help:      "Short URL visits, by outcome.",
+	}, []string{"result"})
+
+	// Shortens counts shortened URLs, single and bulk, by ShortenSuccess or error code
+	Shortens = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "shorten_total",
+		Help:      "URLs shortened, by result: success or the error code returned.",
+	}, []string{"result"})
+)
+
+// RegisterDB exports the connection pool statistics of a database, labelled with name
+func RegisterDB(db *sql.DB, name string) {
+	prometheus.MustRegister(collectors.NewDBStatsCollector(db, name))
+}
+
+// RegisterCache exports the counters of the link cache
+func RegisterCache(stats func() store.CacheStats) {
+	counter := func(name, help string, value func(store.CacheStats) uint64) {
+		promauto.NewCounterFunc(prometheus.CounterOpts{Namespace: namespace, Name: name, Help: help},
+			func() float64 { return float64(value(stats())) })
+	}
+	counter("cache_hits_total", "Link lookups answered by the cache.", func(s store.CacheStats) uint64 { return s.Hits })
+	counter("cache_misses_total", "Link lookups that missed the cache.", func(s store.CacheStats) uint64 { return s.Misses })
+	counter("cache_evictions_total", "Links evicted from the cache to make room.", func(s store.CacheStats) uint64 { return s.Evictions })
+	promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "cache_entries",
+		Help:      "Links currently cached.",
+	}, func() float64 { return float64(stats().Entries) })
+}
+
+// RegisterClickRecorder exports the state of the click event queue
+func RegisterClickRecorder(recorder *analytics.Recorder) {
+	promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "click_queue_depth",
+		Help:      "Click events waiting to be written.",
+	}, func() float64 { return float64(recorder.QueueDepth()) })
+	promauto.NewCounterFunc(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "click_events_dropped_total",
+		Help:      "Click events dropped because the queue was full.",
+	}, func() float64 { return float64(recorder.Dropped()) })
+}
@@ -0,0 +1,14 @@
+package middleware
+
+import (
+	"GoShort/pkg/config"
+	"net/http"
+)
+
+// ConfigSnapshot pins the current configuration to the request so that a reload in the
+// middle of the request cannot mix settings from two configurations
+func ConfigSnapshot(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(config.WithContext(r.Context(), config.Current())))
+	})
+}
@@ -0,0 +1,78 @@
+package middleware
+
+import (
+	"GoShort/pkg/logger"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// RequestIDHeader carries the ID of a request, from a proxy in front of GoShort or back to
+// the client
+const RequestIDHeader = "X-Request-ID"
+
+// validRequestID matches request IDs safe to log as they are
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._-]{1,128}$`)
+
+// quietRoutes are logged at the debug level since probes and scrapers hit them constantly
+var quietRoutes = map[string]bool{
+	"/healthz": true,
+	"/readyz":  true,
+	"/metrics": true,
+}
+
+// RequestID tags every request with an ID, kept from the X-Request-ID header when a proxy
+// set one, and returns it in the response so that logs can be matched to a request
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if !validRequestID.MatchString(id) {
+			id = newRequestID()
+		}
+		w.Header().Set(RequestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(logger.WithRequestID(r.Context(), id)))
+	})
+}
+
+// newRequestID returns a random request ID
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// AccessLog logs every request once it has been served, with sensitive query parameters
+// redacted
+func AccessLog(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		recorder := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(recorder, r)
+
+		route := routeTemplate(r)
+		path := r.URL.Path
+		if query := logger.RedactQuery(r.URL.RawQuery); query != "" {
+			path += "?" + query
+		}
+		fields := logger.Fields{
+			"method":     r.Method,
+			"path":       path,
+			"route":      route,
+			"status":     recorder.Status(),
+			"latency_ms": float64(time.Since(start).Microseconds()) / 1000,
+		}
+		if shortURL := mux.Vars(r)["shortURL"]; shortURL != "" {
+			fields["short_code"] = shortURL
+		}
+
+		if quietRoutes[route] {
+			logger.DebugContext(r.Context(), "Request served", fields)
+		} else {
+			logger.InfoContext(r.Context(), "Request served", fields)
+		}
+	})
+}
@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"GoShort/pkg/logger"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestID(t *testing.T) {
+	var seen string
+	handler := RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = logger.RequestID(r.Context())
+	}))
+
+	// A new ID is generated when the client sends none
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/promo", nil))
+	assert.Len(t, seen, 32)
+	assert.Equal(t, seen, w.Header().Get(RequestIDHeader))
+
+	// The ID set by a proxy is kept
+	r := httptest.NewRequest(http.MethodGet, "/promo", nil)
+	r.Header.Set(RequestIDHeader, "lb-1234")
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	assert.Equal(t, "lb-1234", seen)
+	assert.Equal(t, "lb-1234", w.Header().Get(RequestIDHeader))
+
+	// IDs that could forge log lines are replaced
+	r = httptest.NewRequest(http.MethodGet, "/promo", nil)
+	r.Header.Set(RequestIDHeader, "x\nlevel=error")
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+	assert.Len(t, seen, 32)
+}
+
+func TestAccessLog(t *testing.T) {
+	var out bytes.Buffer
+	logger.Init()
+	logger.SetOutput(&out)
+	require.NoError(t, logger.SetFormat("json"))
+	t.Cleanup(logger.Init)
+
+	router := mux.NewRouter()
+	router.Use(RequestID, AccessLog)
+	router.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {})
+	router.HandleFunc("/{shortURL}", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "https://example.com", http.StatusFound)
+	})
+
+	r := httptest.NewRequest(http.MethodGet, "/promo?utm_source=mail&access_token=s3cret", nil)
+	r.Header.Set(RequestIDHeader, "req-1")
+	router.ServeHTTP(httptest.NewRecorder(), r)
+
+	// Probes are only logged at the debug level
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+	var entry map[string]interface{}
+	require.NoError(t, json.Unmarshal(out.Bytes(), &entry), out.String())
+	assert.Equal(t, "Request served", entry["msg"])
+	assert.Equal(t, "req-1", entry["request_id"])
+	assert.Equal(t, "GET", entry["method"])
+	assert.Equal(t, "/promo?access_token=REDACTED&utm_source=mail", entry["path"])
+	assert.Equal(t, "/{shortURL}", entry["route"])
+	assert.Equal(t, "promo", entry["short_code"])
+	assert.EqualValues(t, http.StatusFound, entry["status"])
+	assert.Contains(t, entry, "latency_ms")
+	assert.NotContains(t, out.String(), "s3cret")
+}
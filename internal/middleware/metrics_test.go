@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"GoShort/internal/metrics"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetrics(t *testing.T) {
+	router := mux.NewRouter()
+	router.Use(Metrics)
+	router.HandleFunc("/{shortURL}", func(w http.ResponseWriter, r *http.Request) {
+		if mux.Vars(r)["shortURL"] == "missing" {
+			http.NotFound(w, r)
+			return
+		}
+		w.(http.Flusher).Flush()
+		w.Write([]byte("ok"))
+	})
+
+	found := metrics.HTTPRequests.WithLabelValues("/{shortURL}", http.MethodGet, "200")
+	missing := metrics.HTTPRequests.WithLabelValues("/{shortURL}", http.MethodGet, "404")
+	foundBefore, missingBefore := testutil.ToFloat64(found), testutil.ToFloat64(missing)
+
+	for _, path := range []string{"/promo", "/other", "/missing"} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	// Every short URL is counted under its route template
+	assert.Equal(t, foundBefore+2, testutil.ToFloat64(found))
+	assert.Equal(t, missingBefore+1, testutil.ToFloat64(missing))
+}
+
+func TestStatusRecorderUnwraps(t *testing.T) {
+	w := httptest.NewRecorder()
+	recorder := &statusRecorder{ResponseWriter: w}
+	assert.NoError(t, http.NewResponseController(recorder).Flush())
+	assert.True(t, w.Flushed)
+	assert.Equal(t, http.StatusOK, recorder.status)
+}
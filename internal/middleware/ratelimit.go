@@ -0,0 +1,153 @@
+package middleware
+
+import (
+	"GoShort/pkg/config"
+	"math"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// clientIdleTimeout is how long an idle client's bucket is kept
+const clientIdleTimeout = 10 * time.Minute
+
+// client is the token bucket of one client
+type client struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// RateLimiter limits each client to the configured number of requests per minute. The limits
+// are read from the request's configuration, so reloaded limits apply immediately.
+type RateLimiter struct {
+	mu        sync.Mutex
+	clients   map[string]*client
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+// NewRateLimiter creates a RateLimiter
+func NewRateLimiter() *RateLimiter {
+	return &RateLimiter{clients: make(map[string]*client), now: time.Now}
+}
+
+// Middleware rejects requests from clients over their limit with 429 Too Many Requests
+func (l *RateLimiter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cfg := config.FromContext(r.Context()).RateLimit
+		if !cfg.Enabled {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if ok, retryAfter := l.allow(clientIP(r, cfg.Proxies()), cfg); !ok {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+			http.Error(w, "Too many requests", http.StatusTooManyRequests)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// allow takes a token from the client's bucket, reporting how long to wait when it is empty
+func (l *RateLimiter) allow(ip string, cfg config.RateLimitConfig) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	limit := rate.Limit(float64(cfg.RequestsPerMinute) / 60)
+	c, ok := l.clients[ip]
+	if !ok {
+		c = &client{limiter: rate.NewLimiter(limit, cfg.Burst)}
+		l.clients[ip] = c
+	} else if c.limiter.Limit() != limit || c.limiter.Burst() != cfg.Burst {
+		// The limits were reloaded since this client was last seen
+		c.limiter.SetLimitAt(now, limit)
+		c.limiter.SetBurstAt(now, cfg.Burst)
+	}
+	c.lastSeen = now
+
+	reservation := c.limiter.ReserveN(now, 1)
+	if delay := reservation.DelayFrom(now); delay > 0 {
+		reservation.CancelAt(now)
+		return false, delay
+	}
+	return true, 0
+}
+
+// sweep forgets clients that have been idle for a while
+func (l *RateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < time.Minute {
+		return
+	}
+	l.lastSweep = now
+	for ip, c := range l.clients {
+		if now.Sub(c.lastSeen) > clientIdleTimeout {
+			delete(l.clients, ip)
+		}
+	}
+}
+
+// clientIP identifies the client of a request. Forwarding headers are only believed when the
+// request comes from a trusted proxy, and then the right-most X-Forwarded-For hop that is not a
+// trusted proxy is the client, since hops to its left can be forged by the client.
+func clientIP(r *http.Request, trusted []netip.Prefix) string {
+	remote := remoteHost(r)
+	if !isTrustedProxy(remote, trusted) {
+		return remote
+	}
+
+	if forwarded := r.Header.Values("X-Forwarded-For"); len(forwarded) > 0 {
+		hops := strings.Split(strings.Join(forwarded, ","), ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			hop := strings.TrimSpace(hops[i])
+			if hop == "" {
+				continue
+			}
+			if i == 0 || !isTrustedProxy(hop, trusted) {
+				return hop
+			}
+		}
+	}
+	if ip := strings.TrimSpace(r.Header.Get("X-Real-IP")); ip != "" {
+		return ip
+	}
+	return remote
+}
+
+// FromTrustedProxy reports whether r was sent by one of the trusted proxies, whose forwarding
+// headers can then be believed
+func FromTrustedProxy(r *http.Request, trusted []netip.Prefix) bool {
+	return isTrustedProxy(remoteHost(r), trusted)
+}
+
+// remoteHost returns the address r was sent from, without its port
+func remoteHost(r *http.Request) string {
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
+
+// isTrustedProxy reports whether ip is within one of the trusted prefixes
+func isTrustedProxy(ip string, trusted []netip.Prefix) bool {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, prefix := range trusted {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
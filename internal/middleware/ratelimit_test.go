@@ -0,0 +1,97 @@
+package middleware
+
+import (
+	"GoShort/pkg/config"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// request sends a request from ip through handler with cfg as its configuration
+func request(handler http.Handler, cfg *config.Config, ip string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/v1/shorten", nil)
+	req.RemoteAddr = ip + ":1234"
+	req = req.WithContext(config.WithContext(req.Context(), cfg))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	return w
+}
+
+func TestRateLimiter(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	limiter := NewRateLimiter()
+	limiter.now = func() time.Time { return now }
+	handler := limiter.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	cfg := config.Default()
+	cfg.RateLimit = config.RateLimitConfig{Enabled: true, RequestsPerMinute: 60, Burst: 2}
+
+	assert.Equal(t, http.StatusOK, request(handler, cfg, "10.0.0.1").Code)
+	assert.Equal(t, http.StatusOK, request(handler, cfg, "10.0.0.1").Code)
+	w := request(handler, cfg, "10.0.0.1")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "1", w.Header().Get("Retry-After"))
+
+	// Other clients have their own bucket
+	assert.Equal(t, http.StatusOK, request(handler, cfg, "10.0.0.2").Code)
+
+	// Tokens are refilled over time
+	now = now.Add(time.Second)
+	assert.Equal(t, http.StatusOK, request(handler, cfg, "10.0.0.1").Code)
+
+	// Reloaded limits apply to known clients
+	reloaded := config.Default()
+	reloaded.RateLimit = config.RateLimitConfig{Enabled: true, RequestsPerMinute: 600, Burst: 10}
+	now = now.Add(time.Second)
+	assert.Equal(t, http.StatusOK, request(handler, reloaded, "10.0.0.1").Code)
+	now = now.Add(time.Second)
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, http.StatusOK, request(handler, reloaded, "10.0.0.1").Code)
+	}
+
+	// Disabling the limit lets everything through
+	reloaded.RateLimit.Enabled = false
+	for i := 0; i < 20; i++ {
+		assert.Equal(t, http.StatusOK, request(handler, reloaded, "10.0.0.1").Code)
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	trusted := config.RateLimitConfig{TrustedProxies: []string{"172.16.0.0/12", "10.0.0.5"}}.Proxies()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "172.17.0.1:5000"
+	req.Header.Set("X-Forwarded-For", "203.0.113.7, 172.17.0.1")
+
+	// Forwarding headers are ignored unless the request comes from a trusted proxy
+	assert.Equal(t, "172.17.0.1", clientIP(req, nil))
+	assert.Equal(t, "203.0.113.7", clientIP(req, trusted))
+
+	// A client cannot pick its address by prepending hops or setting X-Real-IP
+	req.Header.Set("X-Forwarded-For", "198.51.100.1, 203.0.113.7, 10.0.0.5")
+	req.Header.Set("X-Real-IP", "198.51.100.2")
+	assert.Equal(t, "203.0.113.7", clientIP(req, trusted))
+
+	// X-Real-IP set by a trusted proxy is used when there is no X-Forwarded-For
+	req.Header.Del("X-Forwarded-For")
+	assert.Equal(t, "198.51.100.2", clientIP(req, trusted))
+
+	req.RemoteAddr = "203.0.113.9:5000"
+	assert.Equal(t, "203.0.113.9", clientIP(req, trusted))
+}
+
+func TestConfigSnapshot(t *testing.T) {
+	cfg := config.Default()
+	config.Set(cfg)
+	defer config.Set(config.Default())
+
+	var seen *config.Config
+	handler := ConfigSnapshot(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		config.Set(config.Default())
+		seen = config.FromContext(r.Context())
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Same(t, cfg, seen, "A reload during the request should not change its configuration")
+}
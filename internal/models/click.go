@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// ClickEvent represents a single visit to a short URL
+type ClickEvent struct {
+	ID        uint      `gorm:"primaryKey"`
+	URLID     uint      `gorm:"index;not null"`
+	Domain    string    `gorm:"not null;default:''"` // Custom domain of the short URL, empty for the default namespace
+	ShortURL  string    `gorm:"not null"`
+	VariantID *uint     // Variant served when the URL is split-tested
+	Referrer  string    // Referer header of the visit
+	UserAgent string    // User-Agent header of the visit
+	CreatedAt time.Time `gorm:"autoCreateTime;index"`
+}
@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// Branding holds the white-label settings of a domain. Empty fields fall back to the global branding.
+type Branding struct {
+	Title          string
+	Description    string
+	Keywords       string
+	Author         string
+	ThemeColor     string
+	LogoText       string
+	PrimaryColor   string
+	SecondaryColor string
+	HeaderTitle    string
+	FooterText     string
+	FooterLink     string
+}
+
+// Domain represents a custom hostname serving its own namespace of short URLs
+type Domain struct {
+	ID              uint      `gorm:"primaryKey"`
+	Host            string    `gorm:"uniqueIndex;not null"`
+	DefaultRedirect string    // Where unknown short URLs on this domain are sent
+	Branding        Branding  `gorm:"embedded;embeddedPrefix:brand_"`
+	CreatedAt       time.Time `gorm:"autoCreateTime"`
+}
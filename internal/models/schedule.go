@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// URLSchedule represents a destination that takes over a URL from StartsAt onwards
+type URLSchedule struct {
+	ID       uint      `gorm:"primaryKey"`
+	URLID    uint      `gorm:"index;not null"`
+	LongURL  string    `gorm:"not null"`
+	StartsAt time.Time `gorm:"type:timestamp;not null"`
+}
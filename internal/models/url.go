@@ -4,10 +4,22 @@ import "time"
 
 // URL represents the structure of a shortened URL
 type URL struct {
-	ID        uint      `gorm:"primaryKey"`
-	LongURL   string    `gorm:"not null"`
-	ShortURL  string    `gorm:"uniqueIndex;not null"`
-	CreatedAt time.Time `gorm:"autoCreateTime"`
-	Expiry    *time.Time `gorm:"type:timestamp"` // Optional expiry date
-	Clicks    int       `gorm:"default:0"`
+	ID                uint          `gorm:"primaryKey"`
+	LongURL           string        `gorm:"not null"`
+	Domain            string        `gorm:"uniqueIndex:idx_urls_domain_short_url;not null;default:''"` // Custom domain host, empty for the default namespace
+	ShortURL          string        `gorm:"uniqueIndex:idx_urls_domain_short_url;not null"`
+	CreatedAt         time.Time     `gorm:"autoCreateTime"`
+	Expiry            *time.Time    `gorm:"type:timestamp"` // Optional expiry date
+	ActivatesAt       *time.Time    `gorm:"type:timestamp"` // Optional activation date
+	Clicks            int           `gorm:"default:0"`
+	Preview           bool          `gorm:"default:false"` // Show the preview page instead of redirecting
+	OGTitle           string        // Optional social card title
+	OGDescription     string        // Optional social card description
+	OGImage           string        // Optional social card image URL
+	Title             string        // Destination page title, fetched in the background
+	Description       string        // Destination page description, fetched in the background
+	FaviconURL        string        // Destination page favicon, fetched in the background
+	MetadataFetchedAt *time.Time    `gorm:"type:timestamp"`                               // When the destination metadata was last fetched
+	Variants          []URLVariant  `gorm:"foreignKey:URLID;constraint:OnDelete:CASCADE"` // Optional weighted destinations
+	Schedules         []URLSchedule `gorm:"foreignKey:URLID;constraint:OnDelete:CASCADE"` // Optional scheduled destinations
 }
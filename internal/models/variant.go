@@ -0,0 +1,10 @@
+package models
+
+// URLVariant represents one weighted destination of a split-tested URL
+type URLVariant struct {
+	ID      uint   `gorm:"primaryKey"`
+	URLID   uint   `gorm:"index;not null"`
+	LongURL string `gorm:"not null"`
+	Weight  int    `gorm:"not null"`
+	Clicks  int    `gorm:"default:0"`
+}
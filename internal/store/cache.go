@@ -0,0 +1,366 @@
+package store
+
+import (
+	"GoShort/internal/models"
+	"container/list"
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/sync/singleflight"
+)
+
+// Invalidator is implemented by stores that cache links, so that changes made outside the
+// store can evict the copies they make stale
+type Invalidator interface {
+	// Invalidate drops the cached copy of the link shortURL of domain
+	Invalidate(ctx context.Context, domain, shortURL string)
+}
+
+// DomainFinder is implemented by stores that can look up the custom domains links are served on
+type DomainFinder interface {
+	// FindDomain returns the custom domain host, or nil when host is not one
+	FindDomain(ctx context.Context, host string) (*models.Domain, error)
+	// InvalidateDomain drops any cached lookup of host after the domain was changed
+	InvalidateDomain(ctx context.Context, host string)
+}
+
+// Pinger is implemented by stores that depend on a remote service, to check it can be reached
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// CacheOptions configures a CachedStore
+type CacheOptions struct {
+	Size        int           // Maximum number of cached lookups
+	TTL         time.Duration // How long a found link is cached
+	NegativeTTL time.Duration // How long a missing link is cached, zero disables negative caching
+}
+
+// CacheStats counts the lookups served by a CachedStore
+type CacheStats struct {
+	Hits      uint64 `json:"hits"`
+	Misses    uint64 `json:"misses"`
+	Evictions uint64 `json:"evictions"`
+	Entries   int    `json:"entries"`
+}
+
+// cacheEntry is a cached lookup; a nil url records that the link does not exist
+type cacheEntry struct {
+	key     linkKey
+	url     *models.URL
+	expires time.Time
+}
+
+// domainEntry is a cached domain lookup; a nil domain records that the host is not a custom domain
+type domainEntry struct {
+	domain  *models.Domain
+	expires time.Time
+}
+
+// CachedStore is a read-through LRU cache of link lookups in front of another LinkStore.
+// Entries expire after the TTL or when their link expires, whichever comes first. Custom
+// domain lookups are cached for the TTL too, whether the host is a custom domain or not.
+type CachedStore struct {
+	next LinkStore
+	opts CacheOptions
+
+	mu      sync.Mutex
+	entries map[linkKey]*list.Element
+	order   *list.List // Most recently used first
+	domains map[string]domainEntry
+	version uint64 // Bumped by every invalidation, so that loads racing one are not cached
+	now     func() time.Time
+	loads   singleflight.Group
+
+	domainLoads singleflight.Group
+
+	hits, misses, evictions atomic.Uint64
+}
+
+// NewCachedStore creates a CachedStore in front of next
+func NewCachedStore(next LinkStore, opts CacheOptions) *CachedStore {
+	return &CachedStore{
+		next:    next,
+		opts:    opts,
+		entries: make(map[linkKey]*list.Element),
+		order:   list.New(),
+		domains: make(map[string]domainEntry),
+		now:     time.Now,
+	}
+}
+
+// FindLink returns the link from the cache, loading it from the underlying store on a miss.
+// Concurrent misses for the same link share one load.
+func (s *CachedStore) FindLink(ctx context.Context, domain, shortURL string) (*models.URL, error) {
+	ctx, span := tracer.Start(ctx, "cache.lookup", trace.WithAttributes(attribute.String("cache.layer", "memory")))
+	defer span.End()
+
+	key := linkKey{domain, shortURL}
+	if entry, ok := s.get(key); ok {
+		s.hits.Add(1)
+		span.SetAttributes(attribute.Bool("cache.hit", true))
+		if entry.url == nil {
+			return nil, ErrNotFound
+		}
+		return copyURL(entry.url), nil
+	}
+	s.misses.Add(1)
+	span.SetAttributes(attribute.Bool("cache.hit", false))
+
+	loaded, err, _ := s.loads.Do(domain+"/"+shortURL, func() (interface{}, error) {
+		version := s.currentVersion()
+		url, err := s.next.FindLink(ctx, domain, shortURL)
+		if err == nil || errors.Is(err, ErrNotFound) {
+			s.put(key, url, version)
+		}
+		return url, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return copyURL(loaded.(*models.URL)), nil
+}
+
+// CreateLink stores url and drops any cached miss for its short URL
+func (s *CachedStore) CreateLink(ctx context.Context, url *models.URL) error {
+	if err := s.next.CreateLink(ctx, url); err != nil {
+		return err
+	}
+	s.Evict(url.Domain, url.ShortURL)
+	return nil
+}
+
+// RecordClick counts a visit in the underlying store. Cached click counts are not updated.
+func (s *CachedStore) RecordClick(ctx context.Context, urlID uint, variantID *uint) error {
+	return s.next.RecordClick(ctx, urlID, variantID)
+}
+
+// FindDomain returns the custom domain host from the cache, loading it from the underlying
+// store on a miss. Stores that cannot look domains up have none.
+func (s *CachedStore) FindDomain(ctx context.Context, host string) (*models.Domain, error) {
+	next, ok := s.next.(DomainFinder)
+	if !ok {
+		return nil, nil
+	}
+	if domain, ok := s.getDomain(host); ok {
+		return copyDomain(domain), nil
+	}
+
+	loaded, err, _ := s.domainLoads.Do(host, func() (interface{}, error) {
+		version := s.currentVersion()
+		domain, err := next.FindDomain(ctx, host)
+		if err == nil {
+			s.putDomain(host, domain, version)
+		}
+		return domain, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return copyDomain(loaded.(*models.Domain)), nil
+}
+
+// InvalidateDomain drops the cached lookup of host, here and in the underlying store when it
+// caches too
+func (s *CachedStore) InvalidateDomain(ctx context.Context, host string) {
+	s.EvictDomain(host)
+	if next, ok := s.next.(DomainFinder); ok {
+		next.InvalidateDomain(ctx, host)
+	}
+}
+
+// Warm caches links loaded elsewhere, such as the most visited ones on startup, unless the
+// cache was invalidated since version was read with Version
+func (s *CachedStore) Warm(urls []*models.URL, version uint64) {
+	for _, url := range urls {
+		s.put(linkKey{url.Domain, url.ShortURL}, copyURL(url), version)
+	}
+}
+
+// Version returns the invalidation counter to pass to Warm, read before loading the links
+func (s *CachedStore) Version() uint64 {
+	return s.currentVersion()
+}
+
+// Invalidate drops the cached lookup of the link shortURL of domain, here and in the
+// underlying store when it caches too
+func (s *CachedStore) Invalidate(ctx context.Context, domain, shortURL string) {
+	s.Evict(domain, shortURL)
+	if next, ok := s.next.(Invalidator); ok {
+		next.Invalidate(ctx, domain, shortURL)
+	}
+}
+
+// Ping checks that the underlying store can be reached when it depends on a remote service
+func (s *CachedStore) Ping(ctx context.Context) error {
+	if next, ok := s.next.(Pinger); ok {
+		return next.Ping(ctx)
+	}
+	return nil
+}
+
+// Purge drops every cached lookup, here and in the underlying store when it caches too
+func (s *CachedStore) Purge() {
+	s.EvictAll()
+	if next, ok := s.next.(interface{ Purge() }); ok {
+		next.Purge()
+	}
+}
+
+// Evict drops the cached lookup of the link shortURL of domain from this cache only
+func (s *CachedStore) Evict(domain, shortURL string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.version++
+	if element, ok := s.entries[linkKey{domain, shortURL}]; ok {
+		s.remove(element)
+	}
+}
+
+// EvictDomain drops the cached lookup of host from this cache only
+func (s *CachedStore) EvictDomain(host string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.version++
+	delete(s.domains, host)
+}
+
+// EvictAll drops every cached lookup from this cache only
+func (s *CachedStore) EvictAll() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.version++
+	s.entries = make(map[linkKey]*list.Element)
+	s.order.Init()
+	s.domains = make(map[string]domainEntry)
+}
+
+// Stats returns the cache counters
+func (s *CachedStore) Stats() CacheStats {
+	s.mu.Lock()
+	entries := s.order.Len()
+	s.mu.Unlock()
+	return CacheStats{
+		Hits:      s.hits.Load(),
+		Misses:    s.misses.Load(),
+		Evictions: s.evictions.Load(),
+		Entries:   entries,
+	}
+}
+
+// get returns the live entry for key, marking it as recently used
+func (s *CachedStore) get(key linkKey) (*cacheEntry, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	element, ok := s.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := element.Value.(*cacheEntry)
+	if !s.now().Before(entry.expires) {
+		s.remove(element)
+		return nil, false
+	}
+	s.order.MoveToFront(element)
+	return entry, true
+}
+
+// currentVersion returns the invalidation counter
+func (s *CachedStore) currentVersion() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.version
+}
+
+// put caches the result of a lookup started at version, evicting the least recently used
+// entries when full. Results of lookups that raced an invalidation are not cached.
+func (s *CachedStore) put(key linkKey, url *models.URL, version uint64) {
+	now := s.now()
+	ttl := s.opts.TTL
+	if url == nil {
+		ttl = s.opts.NegativeTTL
+	}
+	if ttl <= 0 || s.opts.Size <= 0 {
+		return
+	}
+	if url != nil {
+		ttl = linkTTL(url, ttl, now)
+	}
+	expires := now.Add(ttl)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.version != version {
+		return
+	}
+	if element, ok := s.entries[key]; ok {
+		s.remove(element)
+	}
+	s.entries[key] = s.order.PushFront(&cacheEntry{key: key, url: url, expires: expires})
+	for s.order.Len() > s.opts.Size {
+		s.remove(s.order.Back())
+		s.evictions.Add(1)
+	}
+}
+
+// getDomain returns the live cached lookup of host
+func (s *CachedStore) getDomain(host string) (*models.Domain, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.domains[host]
+	if !ok || !s.now().Before(entry.expires) {
+		return nil, false
+	}
+	return entry.domain, true
+}
+
+// putDomain caches the result of a domain lookup started at version. Hosts come from requests,
+// so the domain cache is emptied rather than allowed to grow past the cache size.
+func (s *CachedStore) putDomain(host string, domain *models.Domain, version uint64) {
+	if s.opts.TTL <= 0 || s.opts.Size <= 0 {
+		return
+	}
+	expires := s.now().Add(s.opts.TTL)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.version != version {
+		return
+	}
+	if _, ok := s.domains[host]; !ok && len(s.domains) >= s.opts.Size {
+		s.domains = make(map[string]domainEntry)
+	}
+	s.domains[host] = domainEntry{domain: domain, expires: expires}
+}
+
+// copyDomain copies domain so that callers cannot change cached domains
+func copyDomain(domain *models.Domain) *models.Domain {
+	if domain == nil {
+		return nil
+	}
+	c := *domain
+	return &c
+}
+
+// linkTTL shortens ttl so that a cached link does not outlive its expiry
+func linkTTL(url *models.URL, ttl time.Duration, now time.Time) time.Duration {
+	if url.Expiry != nil && url.Expiry.After(now) && url.Expiry.Sub(now) < ttl {
+		return url.Expiry.Sub(now)
+	}
+	return ttl
+}
+
+// remove drops an entry; the caller holds the lock
+func (s *CachedStore) remove(element *list.Element) {
+	s.order.Remove(element)
+	delete(s.entries, element.Value.(*cacheEntry).key)
+}
@@ -0,0 +1,192 @@
+package store
+
+import (
+	"GoShort/internal/models"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// countingStore counts the lookups reaching the wrapped store
+type countingStore struct {
+	LinkStore
+	lookups int
+}
+
+func (s *countingStore) FindLink(ctx context.Context, domain, shortURL string) (*models.URL, error) {
+	s.lookups++
+	return s.LinkStore.FindLink(ctx, domain, shortURL)
+}
+
+// countingDomains counts the domain lookups reaching the wrapped store
+type countingDomains struct {
+	LinkStore
+	domains map[string]*models.Domain
+	lookups int
+}
+
+func (s *countingDomains) FindDomain(_ context.Context, host string) (*models.Domain, error) {
+	s.lookups++
+	return s.domains[host], nil
+}
+
+func (s *countingDomains) InvalidateDomain(context.Context, string) {}
+
+// newTestCache creates a CachedStore over an in-memory store with a controllable clock
+func newTestCache(opts CacheOptions) (*CachedStore, *countingStore, *time.Time) {
+	backend := &countingStore{LinkStore: NewMemoryStore()}
+	cache := NewCachedStore(backend, opts)
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+	return cache, backend, &now
+}
+
+func TestCachedStoreHitsAndMisses(t *testing.T) {
+	ctx := context.Background()
+	cache, backend, now := newTestCache(CacheOptions{Size: 10, TTL: time.Minute, NegativeTTL: 10 * time.Second})
+	assert.NoError(t, cache.CreateLink(ctx, &models.URL{LongURL: "https://example.com", ShortURL: "promo"}))
+
+	for i := 0; i < 3; i++ {
+		url, err := cache.FindLink(ctx, "", "promo")
+		assert.NoError(t, err)
+		assert.Equal(t, "https://example.com", url.LongURL)
+	}
+	assert.Equal(t, 1, backend.lookups)
+	assert.Equal(t, CacheStats{Hits: 2, Misses: 1, Entries: 1}, cache.Stats())
+
+	// Entries are reloaded once the TTL has passed
+	*now = now.Add(2 * time.Minute)
+	_, err := cache.FindLink(ctx, "", "promo")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, backend.lookups)
+
+	// Callers get copies, so changing one does not change the cache
+	url, _ := cache.FindLink(ctx, "", "promo")
+	url.LongURL = "https://changed.example.com"
+	url, _ = cache.FindLink(ctx, "", "promo")
+	assert.Equal(t, "https://example.com", url.LongURL)
+}
+
+func TestCachedStoreNegativeCaching(t *testing.T) {
+	ctx := context.Background()
+	cache, backend, now := newTestCache(CacheOptions{Size: 10, TTL: time.Minute, NegativeTTL: 10 * time.Second})
+
+	for i := 0; i < 2; i++ {
+		_, err := cache.FindLink(ctx, "", "missing")
+		assert.ErrorIs(t, err, ErrNotFound)
+	}
+	assert.Equal(t, 1, backend.lookups)
+
+	*now = now.Add(11 * time.Second)
+	_, err := cache.FindLink(ctx, "", "missing")
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.Equal(t, 2, backend.lookups)
+
+	// Creating the link replaces the cached miss
+	assert.NoError(t, cache.CreateLink(ctx, &models.URL{LongURL: "https://example.com", ShortURL: "missing"}))
+	_, err = cache.FindLink(ctx, "", "missing")
+	assert.NoError(t, err)
+}
+
+func TestCachedStoreInvalidate(t *testing.T) {
+	ctx := context.Background()
+	cache, backend, _ := newTestCache(CacheOptions{Size: 10, TTL: time.Minute})
+	assert.NoError(t, cache.CreateLink(ctx, &models.URL{LongURL: "https://example.com", ShortURL: "promo", Domain: "go.example.com"}))
+
+	cache.FindLink(ctx, "go.example.com", "promo")
+	cache.Invalidate(ctx, "go.example.com", "promo")
+	cache.FindLink(ctx, "go.example.com", "promo")
+	assert.Equal(t, 2, backend.lookups)
+
+	cache.Purge()
+	cache.FindLink(ctx, "go.example.com", "promo")
+	assert.Equal(t, 3, backend.lookups)
+}
+
+func TestCachedStoreEvictsLeastRecentlyUsed(t *testing.T) {
+	ctx := context.Background()
+	cache, backend, _ := newTestCache(CacheOptions{Size: 2, TTL: time.Minute})
+	for _, short := range []string{"a", "b", "c"} {
+		assert.NoError(t, cache.CreateLink(ctx, &models.URL{LongURL: "https://example.com/" + short, ShortURL: short}))
+	}
+
+	cache.FindLink(ctx, "", "a")
+	cache.FindLink(ctx, "", "b")
+	cache.FindLink(ctx, "", "a") // a is now more recently used than b
+	cache.FindLink(ctx, "", "c") // evicts b
+	assert.Equal(t, 3, backend.lookups)
+
+	cache.FindLink(ctx, "", "a")
+	assert.Equal(t, 3, backend.lookups)
+	cache.FindLink(ctx, "", "b")
+	assert.Equal(t, 4, backend.lookups)
+	assert.Equal(t, 2, cache.Stats().Entries)
+	assert.Equal(t, uint64(2), cache.Stats().Evictions)
+}
+
+func TestCachedStoreExpiresWithLink(t *testing.T) {
+	ctx := context.Background()
+	cache, backend, now := newTestCache(CacheOptions{Size: 10, TTL: time.Hour})
+	expiry := now.Add(time.Minute)
+	assert.NoError(t, cache.CreateLink(ctx, &models.URL{LongURL: "https://example.com", ShortURL: "promo", Expiry: &expiry}))
+
+	cache.FindLink(ctx, "", "promo")
+	*now = now.Add(2 * time.Minute)
+	cache.FindLink(ctx, "", "promo")
+	assert.Equal(t, 2, backend.lookups, "The entry should not outlive the link")
+}
+
+func TestCachedStoreDomains(t *testing.T) {
+	ctx := context.Background()
+	backend := &countingDomains{LinkStore: NewMemoryStore(), domains: map[string]*models.Domain{"brand.example": {Host: "brand.example"}}}
+	cache := NewCachedStore(backend, CacheOptions{Size: 2, TTL: time.Minute})
+
+	// Custom domains and other hosts are both remembered
+	for i := 0; i < 3; i++ {
+		domain, err := cache.FindDomain(ctx, "brand.example")
+		assert.NoError(t, err)
+		assert.Equal(t, "brand.example", domain.Host)
+		domain, err = cache.FindDomain(ctx, "go.example")
+		assert.NoError(t, err)
+		assert.Nil(t, domain)
+	}
+	assert.Equal(t, 2, backend.lookups)
+
+	// Saving or deleting a domain drops its lookup
+	backend.domains["go.example"] = &models.Domain{Host: "go.example"}
+	cache.InvalidateDomain(ctx, "go.example")
+	domain, err := cache.FindDomain(ctx, "go.example")
+	assert.NoError(t, err)
+	assert.NotNil(t, domain)
+	assert.Equal(t, 3, backend.lookups)
+
+	// Unknown hosts cannot grow the cache past its size
+	_, err = cache.FindDomain(ctx, "other.example")
+	assert.NoError(t, err)
+	assert.LessOrEqual(t, len(cache.domains), 2)
+
+	// Stores that cannot look domains up have none
+	domain, err = NewCachedStore(NewMemoryStore(), CacheOptions{Size: 2, TTL: time.Minute}).FindDomain(ctx, "brand.example")
+	assert.NoError(t, err)
+	assert.Nil(t, domain)
+}
+
+func TestCachedStoreWarm(t *testing.T) {
+	ctx := context.Background()
+	cache, backend, _ := newTestCache(CacheOptions{Size: 10, TTL: time.Minute})
+	url := &models.URL{LongURL: "https://example.com", ShortURL: "promo"}
+	assert.NoError(t, backend.CreateLink(ctx, url))
+
+	cache.Warm([]*models.URL{url}, cache.Version())
+	_, err := cache.FindLink(ctx, "", "promo")
+	assert.NoError(t, err)
+	assert.Zero(t, backend.lookups, "Warmed links are served from the cache")
+
+	// Links loaded before an invalidation are not cached
+	version := cache.Version()
+	cache.Invalidate(ctx, "", "promo")
+	cache.Warm([]*models.URL{url}, version)
+	assert.Zero(t, cache.Stats().Entries)
+}
@@ -0,0 +1,98 @@
+package store
+
+import (
+	"GoShort/internal/models"
+	"context"
+	"slices"
+	"sync"
+	"time"
+)
+
+// MemoryStore is a LinkStore that keeps links in memory, for tests and throwaway instances
+type MemoryStore struct {
+	mu     sync.RWMutex
+	links  map[linkKey]*models.URL
+	byID   map[uint]*models.URL
+	nextID uint
+}
+
+// NewMemoryStore creates an empty MemoryStore
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{links: make(map[linkKey]*models.URL), byID: make(map[uint]*models.URL)}
+}
+
+// FindLink returns a copy of the link shortURL of domain
+func (s *MemoryStore) FindLink(_ context.Context, domain, shortURL string) (*models.URL, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	url, ok := s.links[linkKey{domain, shortURL}]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return copyURL(url), nil
+}
+
+// CreateLink stores a copy of url and assigns IDs to it, its variants and its schedules
+func (s *MemoryStore) CreateLink(_ context.Context, url *models.URL) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := linkKey{url.Domain, url.ShortURL}
+	if _, taken := s.links[key]; taken {
+		return ErrConflict
+	}
+
+	// IDs come from one sequence, which is enough to keep them unique per table
+	url.ID = s.id()
+	if url.CreatedAt.IsZero() {
+		url.CreatedAt = time.Now()
+	}
+	for i := range url.Variants {
+		url.Variants[i].ID = s.id()
+		url.Variants[i].URLID = url.ID
+	}
+	for i := range url.Schedules {
+		url.Schedules[i].ID = s.id()
+		url.Schedules[i].URLID = url.ID
+	}
+
+	stored := copyURL(url)
+	s.links[key] = stored
+	s.byID[stored.ID] = stored
+	return nil
+}
+
+// RecordClick increments the click counters of a link and its variant
+func (s *MemoryStore) RecordClick(_ context.Context, urlID uint, variantID *uint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	url, ok := s.byID[urlID]
+	if !ok {
+		return ErrNotFound
+	}
+	url.Clicks++
+	if variantID != nil {
+		for i := range url.Variants {
+			if url.Variants[i].ID == *variantID {
+				url.Variants[i].Clicks++
+			}
+		}
+	}
+	return nil
+}
+
+// id returns the next free ID
+func (s *MemoryStore) id() uint {
+	s.nextID++
+	return s.nextID
+}
+
+// copyURL copies url so that callers cannot change stored links
+func copyURL(url *models.URL) *models.URL {
+	c := *url
+	c.Variants = slices.Clone(url.Variants)
+	c.Schedules = slices.Clone(url.Schedules)
+	return &c
+}
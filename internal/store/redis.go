@@ -0,0 +1,275 @@
+package store
+
+import (
+	"GoShort/internal/models"
+	"GoShort/pkg/logger"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// missMarker is cached in Redis for short URLs that do not exist
+const missMarker = "-"
+
+// versionTTL is how long the invalidation counter of a link is kept, far longer than a lookup
+// can take
+const versionTTL = time.Hour
+
+// fillScript caches a looked up link only if neither the link nor the whole cache was
+// invalidated since the lookup started, so that a lookup racing an invalidation cannot write
+// the old row back. KEYS are the link, its version and the cache generation; ARGV are the
+// versions read before the lookup, the value and its TTL in milliseconds.
+var fillScript = redis.NewScript(`
+local current = (redis.call('GET', KEYS[2]) or '0') .. ':' .. (redis.call('GET', KEYS[3]) or '0')
+if current ~= ARGV[1] then
+	return 0
+end
+redis.call('SET', KEYS[1], ARGV[2], 'PX', ARGV[3])
+return 1
+`)
+
+// invalidation is the message published when cached links or domains must be dropped
+type invalidation struct {
+	Domain   string `json:"domain,omitempty"`
+	ShortURL string `json:"short_url,omitempty"`
+	Host     string `json:"host,omitempty"` // Drop the cached lookup of a custom domain instead
+	All      bool   `json:"all,omitempty"`  // Drop every cached link and domain
+}
+
+// RedisStore is a read-through cache of link lookups kept in Redis, and so shared by every
+// replica, in front of another LinkStore. Invalidations are published so that replicas can
+// drop their own in-process copies. Redis failures are logged and the lookups fall through
+// to the underlying store.
+type RedisStore struct {
+	next   LinkStore
+	client redis.UniversalClient
+	prefix string
+	opts   CacheOptions
+}
+
+// NewRedisStore creates a RedisStore in front of next. Keys and the invalidation channel
+// are namespaced with prefix; opts.Size is not used since Redis manages its own memory.
+func NewRedisStore(next LinkStore, client redis.UniversalClient, prefix string, opts CacheOptions) *RedisStore {
+	return &RedisStore{next: next, client: client, prefix: prefix, opts: opts}
+}
+
+// FindLink returns the link from Redis, loading it from the underlying store on a miss
+func (s *RedisStore) FindLink(ctx context.Context, domain, shortURL string) (*models.URL, error) {
+	ctx, span := tracer.Start(ctx, "cache.lookup", trace.WithAttributes(attribute.String("cache.layer", "redis")))
+	defer span.End()
+
+	key := s.key(domain, shortURL)
+	cached, err := s.client.Get(ctx, key).Result()
+	switch {
+	case err == nil && cached == missMarker:
+		span.SetAttributes(attribute.Bool("cache.hit", true))
+		return nil, ErrNotFound
+	case err == nil:
+		var url models.URL
+		if err := json.Unmarshal([]byte(cached), &url); err == nil {
+			span.SetAttributes(attribute.Bool("cache.hit", true))
+			return &url, nil
+		}
+		logger.WarnContext(ctx, "Dropping unreadable cached link", logger.Fields{"key": key})
+	case !errors.Is(err, redis.Nil):
+		span.RecordError(err)
+		logger.ErrorContext(ctx, "Failed to read cached link", logger.Fields{"key": key, "error": err})
+	}
+	span.SetAttributes(attribute.Bool("cache.hit", false))
+
+	version, versionErr := s.version(ctx, domain, shortURL)
+	url, err := s.next.FindLink(ctx, domain, shortURL)
+	if versionErr != nil {
+		return url, err
+	}
+	switch {
+	case errors.Is(err, ErrNotFound):
+		s.fill(ctx, domain, shortURL, missMarker, s.opts.NegativeTTL, version)
+	case err == nil:
+		if data, err := json.Marshal(url); err == nil {
+			s.fill(ctx, domain, shortURL, string(data), linkTTL(url, s.opts.TTL, time.Now()), version)
+		}
+	}
+	return url, err
+}
+
+// CreateLink stores url and drops any cached miss for its short URL on every replica
+func (s *RedisStore) CreateLink(ctx context.Context, url *models.URL) error {
+	if err := s.next.CreateLink(ctx, url); err != nil {
+		return err
+	}
+	s.Invalidate(ctx, url.Domain, url.ShortURL)
+	return nil
+}
+
+// RecordClick counts a visit in the underlying store. Cached click counts are not updated.
+func (s *RedisStore) RecordClick(ctx context.Context, urlID uint, variantID *uint) error {
+	return s.next.RecordClick(ctx, urlID, variantID)
+}
+
+// Invalidate deletes the cached link, bumps its version so that lookups in flight do not
+// cache it again, and tells every replica to drop its copy
+func (s *RedisStore) Invalidate(ctx context.Context, domain, shortURL string) {
+	versionKey := s.versionKey(domain, shortURL)
+	_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, s.key(domain, shortURL))
+		pipe.Incr(ctx, versionKey)
+		pipe.Expire(ctx, versionKey, versionTTL)
+		return nil
+	})
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to delete cached link", logger.Fields{"key": s.key(domain, shortURL), "error": err})
+	}
+	s.publish(ctx, invalidation{Domain: domain, ShortURL: shortURL})
+}
+
+// FindDomain looks the custom domain host up in the underlying store. Domains are only cached
+// in process, where they are invalidated through Redis.
+func (s *RedisStore) FindDomain(ctx context.Context, host string) (*models.Domain, error) {
+	if next, ok := s.next.(DomainFinder); ok {
+		return next.FindDomain(ctx, host)
+	}
+	return nil, nil
+}
+
+// InvalidateDomain tells every replica to drop its cached lookup of host
+func (s *RedisStore) InvalidateDomain(ctx context.Context, host string) {
+	s.publish(ctx, invalidation{Host: host})
+}
+
+// Ping checks that Redis can be reached
+func (s *RedisStore) Ping(ctx context.Context) error {
+	return s.client.Ping(ctx).Err()
+}
+
+// Purge deletes every cached link and tells every replica to do the same
+func (s *RedisStore) Purge() {
+	ctx := context.Background()
+	if err := s.client.Incr(ctx, s.generationKey()).Err(); err != nil {
+		logger.Error("Failed to invalidate cached links", logger.Fields{"error": err})
+	}
+	iter := s.client.Scan(ctx, 0, s.prefix+"link:*", 1000).Iterator()
+	var keys []string
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+	}
+	if err := iter.Err(); err != nil {
+		logger.Error("Failed to list cached links", logger.Fields{"error": err})
+	}
+	if len(keys) > 0 {
+		if err := s.client.Del(ctx, keys...).Err(); err != nil {
+			logger.Error("Failed to delete cached links", logger.Fields{"error": err})
+		}
+	}
+	s.publish(ctx, invalidation{All: true})
+}
+
+// Listen passes the invalidations published by every replica, this one included, to evict,
+// evictDomain and evictAll until ctx is done. The subscription is re-established if the
+// connection drops, and since invalidations published meanwhile are lost, evictAll is called
+// each time it is.
+func (s *RedisStore) Listen(ctx context.Context, evict func(domain, shortURL string), evictDomain func(host string), evictAll func()) {
+	sub := s.client.Subscribe(ctx, s.channel())
+	defer sub.Close()
+
+	subscribed := false
+	messages := sub.ChannelWithSubscriptions()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-messages:
+			if !ok {
+				return
+			}
+			switch msg := msg.(type) {
+			case *redis.Subscription:
+				if msg.Kind != "subscribe" {
+					continue
+				}
+				if subscribed {
+					logger.Warn("Resubscribed to cache invalidations, dropping cached links", nil)
+					evictAll()
+				}
+				subscribed = true
+			case *redis.Message:
+				var inv invalidation
+				if err := json.Unmarshal([]byte(msg.Payload), &inv); err != nil {
+					logger.Warn("Ignoring malformed cache invalidation", logger.Fields{"payload": msg.Payload})
+					continue
+				}
+				switch {
+				case inv.All:
+					evictAll()
+				case inv.Host != "":
+					evictDomain(inv.Host)
+				default:
+					evict(inv.Domain, inv.ShortURL)
+				}
+			}
+		}
+	}
+}
+
+// version returns the invalidation counters of a link and of the whole cache
+func (s *RedisStore) version(ctx context.Context, domain, shortURL string) (string, error) {
+	values, err := s.client.MGet(ctx, s.versionKey(domain, shortURL), s.generationKey()).Result()
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to read cached link version", logger.Fields{"key": s.versionKey(domain, shortURL), "error": err})
+		return "", err
+	}
+	counters := make([]string, len(values))
+	for i, value := range values {
+		counters[i] = "0"
+		if value, ok := value.(string); ok {
+			counters[i] = value
+		}
+	}
+	return strings.Join(counters, ":"), nil
+}
+
+// fill caches value for the link for ttl unless it was invalidated since version was read,
+// doing nothing when ttl is not positive
+func (s *RedisStore) fill(ctx context.Context, domain, shortURL, value string, ttl time.Duration, version string) {
+	if ttl <= 0 {
+		return
+	}
+	keys := []string{s.key(domain, shortURL), s.versionKey(domain, shortURL), s.generationKey()}
+	if err := fillScript.Run(ctx, s.client, keys, version, value, ttl.Milliseconds()).Err(); err != nil {
+		logger.ErrorContext(ctx, "Failed to cache link", logger.Fields{"key": keys[0], "error": err})
+	}
+}
+
+// publish sends an invalidation to every replica
+func (s *RedisStore) publish(ctx context.Context, inv invalidation) {
+	data, _ := json.Marshal(inv)
+	if err := s.client.Publish(ctx, s.channel(), data).Err(); err != nil {
+		logger.ErrorContext(ctx, "Failed to publish cache invalidation", logger.Fields{"error": err})
+	}
+}
+
+// key returns the Redis key of a link
+func (s *RedisStore) key(domain, shortURL string) string {
+	return s.prefix + "link:" + domain + "/" + shortURL
+}
+
+// versionKey returns the Redis key counting the invalidations of a link
+func (s *RedisStore) versionKey(domain, shortURL string) string {
+	return s.prefix + "version:" + domain + "/" + shortURL
+}
+
+// generationKey returns the Redis key counting the purges of the whole cache
+func (s *RedisStore) generationKey() string {
+	return s.prefix + "generation"
+}
+
+// channel returns the Redis channel carrying invalidations
+func (s *RedisStore) channel() string {
+	return s.prefix + "invalidate"
+}
@@ -0,0 +1,164 @@
+package store
+
+import (
+	"GoShort/internal/models"
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestRedisStore creates a RedisStore over an in-memory store, backed by a local Redis stand-in
+func newTestRedisStore(t *testing.T) (*RedisStore, *countingStore, *miniredis.Miniredis) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	backend := &countingStore{LinkStore: NewMemoryStore()}
+	return NewRedisStore(backend, client, "goshort:", CacheOptions{TTL: time.Minute, NegativeTTL: 10 * time.Second}), backend, server
+}
+
+func TestRedisStoreCachesLookups(t *testing.T) {
+	ctx := context.Background()
+	s, backend, server := newTestRedisStore(t)
+	assert.NoError(t, s.CreateLink(ctx, &models.URL{
+		LongURL:  "https://example.com",
+		ShortURL: "promo",
+		Variants: []models.URLVariant{{LongURL: "https://example.com/a", Weight: 1}},
+	}))
+
+	for i := 0; i < 3; i++ {
+		url, err := s.FindLink(ctx, "", "promo")
+		assert.NoError(t, err)
+		assert.Equal(t, "https://example.com", url.LongURL)
+		assert.Len(t, url.Variants, 1)
+	}
+	assert.Equal(t, 1, backend.lookups)
+	assert.True(t, server.Exists("goshort:link:/promo"))
+
+	for i := 0; i < 2; i++ {
+		_, err := s.FindLink(ctx, "", "missing")
+		assert.ErrorIs(t, err, ErrNotFound)
+	}
+	assert.Equal(t, 2, backend.lookups)
+
+	// Entries expire in Redis
+	server.FastForward(time.Minute)
+	s.FindLink(ctx, "", "promo")
+	assert.Equal(t, 3, backend.lookups)
+}
+
+func TestRedisStoreFallsBackWhenRedisIsDown(t *testing.T) {
+	ctx := context.Background()
+	s, backend, server := newTestRedisStore(t)
+	assert.NoError(t, s.CreateLink(ctx, &models.URL{LongURL: "https://example.com", ShortURL: "promo"}))
+	server.Close()
+
+	url, err := s.FindLink(ctx, "", "promo")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://example.com", url.LongURL)
+	assert.Equal(t, 1, backend.lookups)
+}
+
+func TestRedisStoreBroadcastsInvalidations(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s, backend, _ := newTestRedisStore(t)
+
+	// Two replicas sharing Redis, each with its own in-process cache
+	local := NewCachedStore(s, CacheOptions{Size: 10, TTL: time.Minute, NegativeTTL: time.Minute})
+	other := NewCachedStore(NewRedisStore(backend, s.client, "goshort:", s.opts), CacheOptions{Size: 10, TTL: time.Minute, NegativeTTL: time.Minute})
+	go s.Listen(ctx, other.Evict, other.EvictDomain, other.EvictAll)
+	waitForSubscriber(t, s)
+
+	// The other replica remembers that the link does not exist
+	_, err := other.FindLink(ctx, "", "promo")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	// Creating it on this replica drops the miss everywhere
+	assert.NoError(t, local.CreateLink(ctx, &models.URL{LongURL: "https://example.com", ShortURL: "promo"}))
+	assert.Eventually(t, func() bool {
+		_, err := other.FindLink(ctx, "", "promo")
+		return err == nil
+	}, time.Second, 10*time.Millisecond)
+
+	local.Purge()
+	assert.Eventually(t, func() bool { return other.Stats().Entries == 0 }, time.Second, 10*time.Millisecond)
+
+	// Domain changes are broadcast too
+	other.domains["brand.example"] = domainEntry{expires: time.Now().Add(time.Minute)}
+	local.InvalidateDomain(ctx, "brand.example")
+	assert.Eventually(t, func() bool {
+		other.mu.Lock()
+		defer other.mu.Unlock()
+		_, ok := other.domains["brand.example"]
+		return !ok
+	}, time.Second, 10*time.Millisecond)
+}
+
+// racingStore runs a hook while a lookup is in flight
+type racingStore struct {
+	LinkStore
+	during func()
+}
+
+func (s *racingStore) FindLink(ctx context.Context, domain, shortURL string) (*models.URL, error) {
+	url, err := s.LinkStore.FindLink(ctx, domain, shortURL)
+	if s.during != nil {
+		s.during()
+	}
+	return url, err
+}
+
+func TestRedisStoreSkipsFillsRacingInvalidations(t *testing.T) {
+	ctx := context.Background()
+	s, backend, server := newTestRedisStore(t)
+	racing := &racingStore{LinkStore: backend}
+	s.next = racing
+	assert.NoError(t, s.CreateLink(ctx, &models.URL{LongURL: "https://example.com", ShortURL: "promo"}))
+
+	// The link changes while it is being looked up, so the old row is not cached
+	racing.during = func() { s.Invalidate(ctx, "", "promo") }
+	_, err := s.FindLink(ctx, "", "promo")
+	assert.NoError(t, err)
+	assert.False(t, server.Exists("goshort:link:/promo"))
+
+	// The same goes for purges
+	racing.during = s.Purge
+	_, err = s.FindLink(ctx, "", "promo")
+	assert.NoError(t, err)
+	assert.False(t, server.Exists("goshort:link:/promo"))
+
+	racing.during = nil
+	_, err = s.FindLink(ctx, "", "promo")
+	assert.NoError(t, err)
+	assert.True(t, server.Exists("goshort:link:/promo"))
+}
+
+func TestRedisStoreEvictsAllAfterReconnecting(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s, _, server := newTestRedisStore(t)
+
+	var evictions atomic.Int32
+	go s.Listen(ctx, func(string, string) {}, func(string) {}, func() { evictions.Add(1) })
+	waitForSubscriber(t, s)
+	assert.Zero(t, evictions.Load(), "Subscribing the first time has nothing to drop")
+
+	// Invalidations published while the connection was down are lost, so everything is dropped
+	server.Close()
+	assert.NoError(t, server.Restart())
+	assert.Eventually(t, func() bool { return evictions.Load() > 0 }, 5*time.Second, 10*time.Millisecond)
+}
+
+// waitForSubscriber waits until a replica listens for invalidations
+func waitForSubscriber(t *testing.T, s *RedisStore) {
+	assert.Eventually(t, func() bool {
+		counts, err := s.client.PubSubNumSub(context.Background(), s.channel()).Result()
+		return err == nil && counts[s.channel()] > 0
+	}, time.Second, 10*time.Millisecond)
+}
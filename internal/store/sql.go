@@ -0,0 +1,114 @@
+package store
+
+import (
+	"GoShort/internal/models"
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// SQLStore is a LinkStore backed by a GORM database, either Postgres or SQLite. The database
+// must be opened with TranslateError so that duplicate short URLs are reported as conflicts.
+type SQLStore struct {
+	db     *gorm.DB
+	read   func() *gorm.DB       // Picks the database lookups run on
+	failed func(*gorm.DB, error) // Told about replicas failing a lookup; may be nil
+}
+
+// NewSQLStore creates a SQLStore using db
+func NewSQLStore(db *gorm.DB) *SQLStore {
+	return NewReplicatedSQLStore(db, func() *gorm.DB { return db }, nil)
+}
+
+// NewReplicatedSQLStore creates a SQLStore writing to primary and looking links up in the
+// database returned by read, usually a read replica. failed, when set, is called with the
+// replicas that fail a lookup for another reason than a missing row.
+func NewReplicatedSQLStore(primary *gorm.DB, read func() *gorm.DB, failed func(*gorm.DB, error)) *SQLStore {
+	return &SQLStore{db: primary, read: read, failed: failed}
+}
+
+// FindLink returns the link shortURL of domain. Lookups a replica fails are run again on the
+// primary, and so are those of links missing from it, as they may have been created before
+// the replica caught up.
+func (s *SQLStore) FindLink(ctx context.Context, domain, shortURL string) (*models.URL, error) {
+	reader := s.read()
+	url, err := findLink(ctx, reader, domain, shortURL)
+	if err != nil && s.retryOnPrimary(ctx, reader, err) {
+		url, err = findLink(ctx, s.db, domain, shortURL)
+	}
+	return url, err
+}
+
+// retryOnPrimary reports whether a lookup that failed with err on reader should run again on
+// the primary, reporting the replica when it failed rather than missed the row
+func (s *SQLStore) retryOnPrimary(ctx context.Context, reader *gorm.DB, err error) bool {
+	if reader == s.db || ctx.Err() != nil {
+		return false
+	}
+	if err != nil && !errors.Is(err, ErrNotFound) && s.failed != nil {
+		s.failed(reader, err)
+	}
+	return true
+}
+
+// findLink loads the link shortURL of domain from db
+func findLink(ctx context.Context, db *gorm.DB, domain, shortURL string) (*models.URL, error) {
+	var url models.URL
+	err := db.WithContext(ctx).Preload("Variants").Preload("Schedules").
+		Where("domain = ? AND short_url = ?", domain, shortURL).First(&url).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &url, nil
+}
+
+// FindDomain returns the custom domain host, or nil when host is not one. Like links, domains
+// are looked up again on the primary when the replica fails or lacks them.
+func (s *SQLStore) FindDomain(ctx context.Context, host string) (*models.Domain, error) {
+	reader := s.read()
+	domain, err := findDomain(ctx, reader, host)
+	if (err != nil || domain == nil) && s.retryOnPrimary(ctx, reader, err) {
+		domain, err = findDomain(ctx, s.db, host)
+	}
+	return domain, err
+}
+
+// findDomain loads the custom domain host from db
+func findDomain(ctx context.Context, db *gorm.DB, host string) (*models.Domain, error) {
+	var domain models.Domain
+	err := db.WithContext(ctx).Where("host = ?", host).First(&domain).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &domain, nil
+}
+
+// InvalidateDomain does nothing since the database is always up to date
+func (s *SQLStore) InvalidateDomain(context.Context, string) {}
+
+// CreateLink stores url, relying on the unique index to reject taken short URLs
+func (s *SQLStore) CreateLink(ctx context.Context, url *models.URL) error {
+	err := s.db.WithContext(ctx).Create(url).Error
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return ErrConflict
+	}
+	return err
+}
+
+// RecordClick increments the click counters of a link and its variant
+func (s *SQLStore) RecordClick(ctx context.Context, urlID uint, variantID *uint) error {
+	db := s.db.WithContext(ctx)
+	if variantID != nil {
+		if err := db.Model(&models.URLVariant{}).Where("id = ?", *variantID).UpdateColumn("clicks", gorm.Expr("clicks + ?", 1)).Error; err != nil {
+			return err
+		}
+	}
+	return db.Model(&models.URL{}).Where("id = ?", urlID).UpdateColumn("clicks", gorm.Expr("clicks + ?", 1)).Error
+}
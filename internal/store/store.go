@@ -0,0 +1,35 @@
+package store
+
+import (
+	"GoShort/internal/models"
+	"context"
+	"errors"
+
+	"go.opentelemetry.io/otel"
+)
+
+var tracer = otel.Tracer("GoShort/internal/store")
+
+// Errors returned by every LinkStore
+var (
+	ErrNotFound = errors.New("link not found")
+	ErrConflict = errors.New("short URL is already taken")
+)
+
+// LinkStore stores short links. Short URLs are unique within the namespace of a domain,
+// where the empty domain is the default namespace.
+type LinkStore interface {
+	// FindLink returns the link shortURL of domain with its variants and schedules, or ErrNotFound
+	FindLink(ctx context.Context, domain, shortURL string) (*models.URL, error)
+	// CreateLink stores url with its variants and schedules and assigns their IDs. It returns
+	// ErrConflict when the short URL is already taken in the domain.
+	CreateLink(ctx context.Context, url *models.URL) error
+	// RecordClick counts a visit to a link and, when variantID is set, to one of its variants
+	RecordClick(ctx context.Context, urlID uint, variantID *uint) error
+}
+
+// linkKey identifies a link within its domain
+type linkKey struct {
+	domain   string
+	shortURL string
+}
@@ -0,0 +1,127 @@
+package store
+
+import (
+	"GoShort/internal/models"
+	"GoShort/migrations"
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newSQLiteDB opens a fresh, migrated SQLite database
+func newSQLiteDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "goshort.db")), &gorm.Config{
+		TranslateError: true,
+		Logger:         logger.Default.LogMode(logger.Silent),
+	})
+	assert.NoError(t, err)
+	_, err = migrations.Up(db)
+	assert.NoError(t, err)
+	t.Cleanup(func() {
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+	})
+	return db
+}
+
+// newSQLiteStore creates a SQLStore on a fresh SQLite database
+func newSQLiteStore(t *testing.T) LinkStore {
+	return NewSQLStore(newSQLiteDB(t))
+}
+
+func TestLinkStores(t *testing.T) {
+	stores := map[string]func(t *testing.T) LinkStore{
+		"memory": func(t *testing.T) LinkStore { return NewMemoryStore() },
+		"sqlite": newSQLiteStore,
+	}
+
+	for name, newStore := range stores {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			s := newStore(t)
+
+			url := &models.URL{
+				LongURL:  "https://example.com",
+				ShortURL: "promo",
+				Variants: []models.URLVariant{{LongURL: "https://example.com/a", Weight: 1}, {LongURL: "https://example.com/b", Weight: 1}},
+			}
+			assert.NoError(t, s.CreateLink(ctx, url))
+			assert.NotZero(t, url.ID)
+			assert.NotZero(t, url.Variants[0].ID)
+
+			// Short URLs are unique per domain only
+			assert.ErrorIs(t, s.CreateLink(ctx, &models.URL{LongURL: "https://example.org", ShortURL: "promo"}), ErrConflict)
+			assert.NoError(t, s.CreateLink(ctx, &models.URL{LongURL: "https://example.org", ShortURL: "promo", Domain: "go.example.com"}))
+
+			found, err := s.FindLink(ctx, "", "promo")
+			assert.NoError(t, err)
+			assert.Equal(t, "https://example.com", found.LongURL)
+			assert.Len(t, found.Variants, 2)
+
+			found, err = s.FindLink(ctx, "go.example.com", "promo")
+			assert.NoError(t, err)
+			assert.Equal(t, "https://example.org", found.LongURL)
+
+			_, err = s.FindLink(ctx, "", "unknown")
+			assert.ErrorIs(t, err, ErrNotFound)
+
+			assert.NoError(t, s.RecordClick(ctx, url.ID, nil))
+			assert.NoError(t, s.RecordClick(ctx, url.ID, &url.Variants[1].ID))
+			found, err = s.FindLink(ctx, "", "promo")
+			assert.NoError(t, err)
+			assert.Equal(t, 2, found.Clicks)
+			assert.Equal(t, 0, found.Variants[0].Clicks)
+			assert.Equal(t, 1, found.Variants[1].Clicks)
+		})
+	}
+}
+
+func TestReplicatedSQLStore(t *testing.T) {
+	ctx := context.Background()
+	primary, replica := newSQLiteDB(t), newSQLiteDB(t)
+	var failed []*gorm.DB
+	s := NewReplicatedSQLStore(primary, func() *gorm.DB { return replica }, func(conn *gorm.DB, err error) { failed = append(failed, conn) })
+
+	// Lookups are served by the replica
+	assert.NoError(t, replica.Create(&models.URL{LongURL: "https://example.com/replica", ShortURL: "promo"}).Error)
+	assert.NoError(t, primary.Create(&models.URL{LongURL: "https://example.com/primary", ShortURL: "promo"}).Error)
+	found, err := s.FindLink(ctx, "", "promo")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://example.com/replica", found.LongURL)
+
+	// Links the replica has not caught up with are found on the primary
+	url := &models.URL{LongURL: "https://example.com/new", ShortURL: "new"}
+	assert.NoError(t, s.CreateLink(ctx, url))
+	found, err = s.FindLink(ctx, "", "new")
+	assert.NoError(t, err)
+	assert.Equal(t, url.ID, found.ID)
+
+	_, err = s.FindLink(ctx, "", "unknown")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	// Domains too are looked up on the primary when the replica lacks them
+	assert.NoError(t, primary.Create(&models.Domain{Host: "brand.example"}).Error)
+	domain, err := s.FindDomain(ctx, "brand.example")
+	assert.NoError(t, err)
+	assert.Equal(t, "brand.example", domain.Host)
+	domain, err = s.FindDomain(ctx, "unknown.example")
+	assert.NoError(t, err)
+	assert.Nil(t, domain)
+	assert.Empty(t, failed, "Missing rows are not failures")
+
+	// Lookups a replica fails are run again on the primary, which gets told about it
+	sqlDB, _ := replica.DB()
+	sqlDB.Close()
+	found, err = s.FindLink(ctx, "", "promo")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://example.com/primary", found.LongURL)
+	domain, err = s.FindDomain(ctx, "brand.example")
+	assert.NoError(t, err)
+	assert.NotNil(t, domain)
+	assert.Equal(t, []*gorm.DB{replica, replica}, failed)
+}
@@ -0,0 +1,94 @@
+package tracing
+
+import (
+	"errors"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+// spanKey stores the span of a statement in its GORM instance settings
+const spanKey = "tracing:span"
+
+// gormPlugin records a span for every statement run through GORM
+type gormPlugin struct {
+	tracer trace.Tracer
+}
+
+// GORM returns a GORM plugin recording a span for every statement run as part of a trace.
+// Statements are recorded with placeholders, never with their parameters.
+func GORM() gorm.Plugin {
+	return &gormPlugin{}
+}
+
+// Name identifies the plugin to GORM
+func (p *gormPlugin) Name() string {
+	return "tracing"
+}
+
+// Initialize registers the callbacks starting and ending spans around each kind of statement,
+// using the tracer provider installed at the time
+func (p *gormPlugin) Initialize(db *gorm.DB) error {
+	p.tracer = otel.Tracer("GoShort/internal/tracing")
+	callbacks := db.Callback()
+	for _, err := range []error{
+		callbacks.Create().Before("gorm:create").Register("tracing:before_create", p.startSpan("create")),
+		callbacks.Create().After("gorm:create").Register("tracing:after_create", endSpan),
+		callbacks.Query().Before("gorm:query").Register("tracing:before_query", p.startSpan("query")),
+		callbacks.Query().After("gorm:query").Register("tracing:after_query", endSpan),
+		callbacks.Update().Before("gorm:update").Register("tracing:before_update", p.startSpan("update")),
+		callbacks.Update().After("gorm:update").Register("tracing:after_update", endSpan),
+		callbacks.Delete().Before("gorm:delete").Register("tracing:before_delete", p.startSpan("delete")),
+		callbacks.Delete().After("gorm:delete").Register("tracing:after_delete", endSpan),
+		callbacks.Row().Before("gorm:row").Register("tracing:before_row", p.startSpan("row")),
+		callbacks.Row().After("gorm:row").Register("tracing:after_row", endSpan),
+		callbacks.Raw().Before("gorm:raw").Register("tracing:before_raw", p.startSpan("raw")),
+		callbacks.Raw().After("gorm:raw").Register("tracing:after_raw", endSpan),
+	} {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// startSpan returns a callback starting the span of a statement as a child of its context.
+// Statements run outside of a trace, such as background batches, are not recorded rather
+// than each starting a trace of their own.
+func (p *gormPlugin) startSpan(operation string) func(tx *gorm.DB) {
+	return func(tx *gorm.DB) {
+		if !trace.SpanContextFromContext(tx.Statement.Context).IsValid() {
+			return
+		}
+		ctx, span := p.tracer.Start(tx.Statement.Context, "gorm."+operation,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(attribute.String("db.system", tx.Dialector.Name())))
+		tx.Statement.Context = ctx
+		tx.InstanceSet(spanKey, span)
+	}
+}
+
+// endSpan ends the span of a statement, recording the SQL run and any error
+func endSpan(tx *gorm.DB) {
+	value, ok := tx.InstanceGet(spanKey)
+	if !ok {
+		return
+	}
+	span := value.(trace.Span)
+	defer span.End()
+
+	if tx.Statement.Table != "" {
+		span.SetAttributes(attribute.String("db.collection.name", tx.Statement.Table))
+	}
+	span.SetAttributes(
+		attribute.String("db.query.text", tx.Statement.SQL.String()),
+		attribute.Int64("db.rows_affected", tx.RowsAffected),
+	)
+	if tx.Error != nil && !errors.Is(tx.Error, gorm.ErrRecordNotFound) {
+		span.RecordError(tx.Error)
+		span.SetStatus(codes.Error, tx.Error.Error())
+	}
+}
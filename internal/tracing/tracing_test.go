@@ -0,0 +1,104 @@
+package tracing
+
+import (
+	"GoShort/pkg/config"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// useTracerProvider installs provider globally for the duration of the test
+func useTracerProvider(t *testing.T, provider *sdktrace.TracerProvider) {
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+}
+
+func TestTracesURL(t *testing.T) {
+	assert.Equal(t, "http://localhost:4318/v1/traces", tracesURL("http://localhost:4318"))
+	assert.Equal(t, "http://localhost:4318/v1/traces", tracesURL("http://localhost:4318/"))
+	assert.Equal(t, "https://collector.example.com/otlp/traces", tracesURL("https://collector.example.com/otlp/traces"))
+}
+
+func TestInitExportsOverOTLP(t *testing.T) {
+	// A stand-in for an OpenTelemetry collector receiving OTLP over HTTP
+	received := make(chan string, 10)
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if len(body) > 0 {
+			received <- r.URL.Path
+		}
+	}))
+	defer collector.Close()
+
+	previous := otel.GetTracerProvider()
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	cfg := config.Default().Tracing
+	cfg.Exporter = "otlp"
+	cfg.Endpoint = collector.URL
+	shutdown, err := Init(context.Background(), cfg, "test")
+	assert.NoError(t, err)
+
+	_, span := otel.Tracer("test").Start(context.Background(), "GET /{shortURL}")
+	span.End()
+	assert.NoError(t, shutdown(context.Background()))
+
+	select {
+	case path := <-received:
+		assert.Equal(t, "/v1/traces", path)
+	default:
+		t.Fatal("Expected the span to be exported to the collector")
+	}
+}
+
+func TestInitWithoutExporter(t *testing.T) {
+	shutdown, err := Init(context.Background(), config.Default().Tracing, "test")
+	assert.NoError(t, err)
+	assert.NoError(t, shutdown(context.Background()))
+}
+
+func TestGORM(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	useTracerProvider(t, sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "goshort.db")), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	assert.NoError(t, err)
+	assert.NoError(t, db.Use(GORM()))
+	assert.NoError(t, db.Exec("CREATE TABLE links (short_url TEXT)").Error)
+
+	// Statements outside a trace are not recorded
+	assert.Empty(t, recorder.Ended())
+
+	ctx, parent := otel.Tracer("test").Start(context.Background(), "GET /{shortURL}")
+	var count int64
+	assert.NoError(t, db.WithContext(ctx).Table("links").Where("short_url = ?", "secret-slug").Count(&count).Error)
+	parent.End()
+
+	spans := recorder.Ended()
+	assert.Len(t, spans, 2)
+	query := spans[0]
+	assert.Equal(t, "gorm.query", query.Name())
+	assert.Equal(t, parent.SpanContext().SpanID(), query.Parent().SpanID())
+	assert.Contains(t, query.Attributes(), attribute.String("db.system", "sqlite"))
+	var statement string
+	for _, attr := range query.Attributes() {
+		if attr.Key == "db.query.text" {
+			statement = attr.Value.AsString()
+		}
+	}
+	assert.Contains(t, statement, "short_url = ?")
+	assert.NotContains(t, statement, "secret-slug", "Expected parameters to be left out")
+}
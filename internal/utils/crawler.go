@@ -0,0 +1,36 @@
+package utils
+
+import "strings"
+
+// crawlerSignatures are user agent fragments of link unfurlers and social media crawlers
+var crawlerSignatures = []string{
+	"facebookexternalhit",
+	"facebookcatalog",
+	"twitterbot",
+	"slackbot",
+	"slack-imgproxy",
+	"linkedinbot",
+	"discordbot",
+	"telegrambot",
+	"whatsapp",
+	"skypeuripreview",
+	"microsoftpreview",
+	"pinterest",
+	"redditbot",
+	"embedly",
+	"mastodon",
+	"googlebot",
+	"bingbot",
+	"applebot",
+}
+
+// IsCrawler checks if a user agent belongs to a known link preview crawler
+func IsCrawler(userAgent string) bool {
+	ua := strings.ToLower(userAgent)
+	for _, signature := range crawlerSignatures {
+		if strings.Contains(ua, signature) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,15 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsCrawler(t *testing.T) {
+	assert.True(t, IsCrawler("Slackbot-LinkExpanding 1.0 (+https://api.slack.com/robots)"))
+	assert.True(t, IsCrawler("Twitterbot/1.0"))
+	assert.True(t, IsCrawler("facebookexternalhit/1.1 (+http://www.facebook.com/externalhit_uatext.php)"))
+	assert.False(t, IsCrawler("Mozilla/5.0 (X11; Linux x86_64; rv:128.0) Gecko/20100101 Firefox/128.0"))
+	assert.False(t, IsCrawler(""))
+}
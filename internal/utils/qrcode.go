@@ -0,0 +1,152 @@
+package utils
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"strconv"
+	"strings"
+
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+// QROptions controls how a QR code is rendered
+type QROptions struct {
+	Size       int         // Width and height of the output in pixels
+	Margin     int         // Quiet zone around the code in modules
+	Level      string      // Error correction level: L, M, Q or H
+	Foreground color.Color // Colour of the dark modules
+	Background color.Color // Colour of the light modules and margin
+}
+
+// qrLevels maps the standard error correction levels to the encoder's recovery levels
+var qrLevels = map[string]qrcode.RecoveryLevel{
+	"L": qrcode.Low,
+	"M": qrcode.Medium,
+	"Q": qrcode.High,
+	"H": qrcode.Highest,
+}
+
+// ValidQRLevel checks if level is one of the standard error correction levels
+func ValidQRLevel(level string) bool {
+	_, ok := qrLevels[level]
+	return ok
+}
+
+// qrModules encodes content and returns its modules surrounded by the requested margin
+func qrModules(content string, opts QROptions) ([][]bool, error) {
+	level, ok := qrLevels[opts.Level]
+	if !ok {
+		return nil, errors.New("invalid error correction level")
+	}
+
+	code, err := qrcode.New(content, level)
+	if err != nil {
+		return nil, err
+	}
+	code.DisableBorder = true
+	bitmap := code.Bitmap()
+
+	total := len(bitmap) + 2*opts.Margin
+	modules := make([][]bool, total)
+	for y := range modules {
+		modules[y] = make([]bool, total)
+	}
+	for y, row := range bitmap {
+		copy(modules[y+opts.Margin][opts.Margin:], row)
+	}
+	return modules, nil
+}
+
+// QRCodePNG renders content as a PNG QR code
+func QRCodePNG(content string, opts QROptions) ([]byte, error) {
+	modules, err := qrModules(content, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// Scale modules to whole pixels and centre the code in any leftover space
+	total := len(modules)
+	size := opts.Size
+	if size < total {
+		size = total
+	}
+	scale := size / total
+	offset := (size - scale*total) / 2
+
+	img := image.NewPaletted(image.Rect(0, 0, size, size), color.Palette{opts.Background, opts.Foreground})
+	for y, row := range modules {
+		for x, dark := range row {
+			if !dark {
+				continue
+			}
+			for dy := 0; dy < scale; dy++ {
+				for dx := 0; dx < scale; dx++ {
+					img.SetColorIndex(offset+x*scale+dx, offset+y*scale+dy, 1)
+				}
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// QRCodeSVG renders content as an SVG QR code
+func QRCodeSVG(content string, opts QROptions) ([]byte, error) {
+	modules, err := qrModules(content, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	total := len(modules)
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`,
+		opts.Size, opts.Size, total, total)
+	fmt.Fprintf(&buf, `<rect width="%d" height="%d" fill="%s"/>`, total, total, HexColor(opts.Background))
+	fmt.Fprintf(&buf, `<path fill="%s" d="`, HexColor(opts.Foreground))
+
+	// Draw each horizontal run of dark modules as a single rectangle
+	for y, row := range modules {
+		for x := 0; x < total; x++ {
+			if !row[x] {
+				continue
+			}
+			start := x
+			for x < total && row[x] {
+				x++
+			}
+			fmt.Fprintf(&buf, "M%d %dh%dv1h-%dz", start, y, x-start, x-start)
+		}
+	}
+	buf.WriteString(`"/></svg>`)
+	return buf.Bytes(), nil
+}
+
+// ParseHexColor parses a #rgb or #rrggbb colour
+func ParseHexColor(s string) (color.Color, error) {
+	hex := strings.TrimPrefix(s, "#")
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	if len(hex) != 6 {
+		return nil, fmt.Errorf("invalid colour %q", s)
+	}
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return nil, fmt.Errorf("invalid colour %q", s)
+	}
+	return color.RGBA{R: uint8(v >> 16), G: uint8(v >> 8), B: uint8(v), A: 0xff}, nil
+}
+
+// HexColor formats a colour as #rrggbb
+func HexColor(c color.Color) string {
+	r, g, b, _ := c.RGBA()
+	return fmt.Sprintf("#%02x%02x%02x", r>>8, g>>8, b>>8)
+}
@@ -0,0 +1,72 @@
+package utils
+
+import (
+	"bytes"
+	"image/color"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQRCodePNG(t *testing.T) {
+	opts := QROptions{
+		Size:       256,
+		Margin:     4,
+		Level:      "M",
+		Foreground: color.RGBA{R: 0x3b, G: 0x82, B: 0xf6, A: 0xff},
+		Background: color.White,
+	}
+
+	data, err := QRCodePNG("https://example.com/abc123", opts)
+	assert.NoError(t, err)
+
+	img, err := png.Decode(bytes.NewReader(data))
+	assert.NoError(t, err)
+	assert.Equal(t, 256, img.Bounds().Dx())
+	assert.Equal(t, 256, img.Bounds().Dy())
+
+	// The corner lies inside the margin and must use the background colour
+	r, g, b, _ := img.At(0, 0).RGBA()
+	assert.Equal(t, [3]uint32{0xffff, 0xffff, 0xffff}, [3]uint32{r, g, b})
+}
+
+func TestQRCodeSVG(t *testing.T) {
+	opts := QROptions{
+		Size:       128,
+		Margin:     2,
+		Level:      "H",
+		Foreground: color.Black,
+		Background: color.White,
+	}
+
+	data, err := QRCodeSVG("https://example.com/abc123", opts)
+	assert.NoError(t, err)
+
+	svg := string(data)
+	assert.True(t, strings.HasPrefix(svg, "<svg"))
+	assert.Contains(t, svg, `width="128"`)
+	assert.Contains(t, svg, `fill="#000000"`)
+	assert.Contains(t, svg, `fill="#ffffff"`)
+}
+
+func TestQRCodeInvalidLevel(t *testing.T) {
+	_, err := QRCodePNG("https://example.com", QROptions{Size: 128, Level: "X", Foreground: color.Black, Background: color.White})
+	assert.Error(t, err)
+}
+
+func TestParseHexColor(t *testing.T) {
+	c, err := ParseHexColor("#3b82f6")
+	assert.NoError(t, err)
+	assert.Equal(t, "#3b82f6", HexColor(c))
+
+	c, err = ParseHexColor("fff")
+	assert.NoError(t, err)
+	assert.Equal(t, "#ffffff", HexColor(c))
+
+	_, err = ParseHexColor("#12345")
+	assert.Error(t, err)
+	_, err = ParseHexColor("#gggggg")
+	assert.Error(t, err)
+}
@@ -2,19 +2,39 @@ package utils
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"net/http"
 	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 )
 
+var tracer = otel.Tracer("GoShort/internal/utils")
+
 // URLScanResponse represents the response from a URL scanning API
 type URLScanResponse struct {
 	Safe bool `json:"safe"`
 }
 
 // CheckMaliciousURL uses an external API to check if a URL is malicious
-func CheckMaliciousURL(apiEndpoint, apiKey, urlToScan string) (bool, error) {
+func CheckMaliciousURL(ctx context.Context, apiEndpoint, apiKey, urlToScan string) (safe bool, err error) {
+	ctx, span := tracer.Start(ctx, "scanner.check", trace.WithSpanKind(trace.SpanKindClient))
+	defer func() {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		} else {
+			span.SetAttributes(attribute.Bool("scanner.safe", safe))
+		}
+		span.End()
+	}()
+
 	client := &http.Client{Timeout: 10 * time.Second}
 
 	// Prepare JSON payload
@@ -25,10 +45,11 @@ func CheckMaliciousURL(apiEndpoint, apiKey, urlToScan string) (bool, error) {
 	}
 
 	// Create HTTP request
-	req, err := http.NewRequest("POST", apiEndpoint, bytes.NewBuffer(payloadBytes))
+	req, err := http.NewRequestWithContext(ctx, "POST", apiEndpoint, bytes.NewBuffer(payloadBytes))
 	if err != nil {
 		return false, err
 	}
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
 	req.Header.Set("Authorization", "Bearer "+apiKey)
 	req.Header.Set("Content-Type", "application/json")
 
@@ -3,8 +3,13 @@ package utils
 import (
 	"net/url"
 	"regexp"
+	"slices"
+	"strings"
 )
 
+// ReservedShortURLs are paths served by GoShort itself that short URLs cannot shadow
+var ReservedShortURLs = []string{"healthz", "readyz", "metrics"}
+
 // ValidateURL checks if a given string is a valid URL
 func ValidateURL(u string) bool {
 	parsedURL, err := url.ParseRequestURI(u)
@@ -14,8 +19,13 @@ func ValidateURL(u string) bool {
 	return true
 }
 
-// ValidateCustomShortURL checks if the custom short URL path is alphanumeric
+// ValidateCustomShortURL checks if the custom short URL path is alphanumeric and not reserved
 func ValidateCustomShortURL(shortURL string) bool {
 	re := regexp.MustCompile("^[a-zA-Z0-9_-]+$")
-	return re.MatchString(shortURL)
+	return re.MatchString(shortURL) && !IsReservedShortURL(shortURL)
+}
+
+// IsReservedShortURL reports whether shortURL is one of the ReservedShortURLs, ignoring case
+func IsReservedShortURL(shortURL string) bool {
+	return slices.Contains(ReservedShortURLs, strings.ToLower(shortURL))
 }
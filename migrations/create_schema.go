@@ -0,0 +1,109 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// createSchema creates the schema as it stood before versioned migrations. Earlier releases
+// created it with AutoMigrate, so it also brings those databases up to date: it only adds
+// what is missing and drops the global short URL index that predates custom domains.
+var createSchema = Migration{
+	ID: "20261019_create_schema",
+	Up: func(tx *gorm.DB) error {
+		if err := tx.AutoMigrate(&schemaURL{}, &schemaURLVariant{}, &schemaURLSchedule{}, &schemaClickEvent{}, &schemaDomain{}); err != nil {
+			return err
+		}
+		if tx.Migrator().HasIndex(&schemaURL{}, "idx_urls_short_url") {
+			return tx.Migrator().DropIndex(&schemaURL{}, "idx_urls_short_url")
+		}
+		return nil
+	},
+	Down: func(tx *gorm.DB) error {
+		return tx.Migrator().DropTable("click_events", "url_variants", "url_schedules", "urls", "domains")
+	},
+}
+
+// The tables below are frozen copies of the models at the time of this migration, so that
+// later changes to the models do not change what it creates.
+
+// schemaURL is the urls table
+type schemaURL struct {
+	ID                uint       `gorm:"primaryKey"`
+	LongURL           string     `gorm:"not null"`
+	Domain            string     `gorm:"uniqueIndex:idx_urls_domain_short_url;not null;default:''"`
+	ShortURL          string     `gorm:"uniqueIndex:idx_urls_domain_short_url;not null"`
+	CreatedAt         time.Time  `gorm:"autoCreateTime"`
+	Expiry            *time.Time `gorm:"type:timestamp"`
+	ActivatesAt       *time.Time `gorm:"type:timestamp"`
+	Clicks            int        `gorm:"default:0"`
+	Preview           bool       `gorm:"default:false"`
+	OGTitle           string
+	OGDescription     string
+	OGImage           string
+	Title             string
+	Description       string
+	FaviconURL        string
+	MetadataFetchedAt *time.Time          `gorm:"type:timestamp"`
+	Variants          []schemaURLVariant  `gorm:"foreignKey:URLID;constraint:OnDelete:CASCADE"`
+	Schedules         []schemaURLSchedule `gorm:"foreignKey:URLID;constraint:OnDelete:CASCADE"`
+}
+
+func (schemaURL) TableName() string { return "urls" }
+
+// schemaURLVariant is the url_variants table
+type schemaURLVariant struct {
+	ID      uint   `gorm:"primaryKey"`
+	URLID   uint   `gorm:"index;not null"`
+	LongURL string `gorm:"not null"`
+	Weight  int    `gorm:"not null"`
+	Clicks  int    `gorm:"default:0"`
+}
+
+func (schemaURLVariant) TableName() string { return "url_variants" }
+
+// schemaURLSchedule is the url_schedules table
+type schemaURLSchedule struct {
+	ID       uint      `gorm:"primaryKey"`
+	URLID    uint      `gorm:"index;not null"`
+	LongURL  string    `gorm:"not null"`
+	StartsAt time.Time `gorm:"type:timestamp;not null"`
+}
+
+func (schemaURLSchedule) TableName() string { return "url_schedules" }
+
+// schemaClickEvent is the click_events table
+type schemaClickEvent struct {
+	ID        uint   `gorm:"primaryKey"`
+	URLID     uint   `gorm:"index;not null"`
+	Domain    string `gorm:"not null;default:''"`
+	ShortURL  string `gorm:"not null"`
+	VariantID *uint
+	Referrer  string
+	UserAgent string
+	CreatedAt time.Time `gorm:"autoCreateTime;index"`
+}
+
+func (schemaClickEvent) TableName() string { return "click_events" }
+
+// schemaDomain is the domains table
+type schemaDomain struct {
+	ID                  uint   `gorm:"primaryKey"`
+	Host                string `gorm:"uniqueIndex;not null"`
+	DefaultRedirect     string
+	BrandTitle          string
+	BrandDescription    string
+	BrandKeywords       string
+	BrandAuthor         string
+	BrandThemeColor     string
+	BrandLogoText       string
+	BrandPrimaryColor   string
+	BrandSecondaryColor string
+	BrandHeaderTitle    string
+	BrandFooterText     string
+	BrandFooterLink     string
+	CreatedAt           time.Time `gorm:"autoCreateTime"`
+}
+
+func (schemaDomain) TableName() string { return "domains" }
@@ -1,48 +1,178 @@
 package migrations
 
 import (
-	"GoShort/internal/db"
-	"log"
+	"GoShort/pkg/logger"
+	"fmt"
+	"time"
 
 	"gorm.io/gorm"
 )
 
-// Migration defines a structure for database migrations
+// Migration is one versioned change to the database schema
 type Migration struct {
-	ID      string
-	Migrate func(tx *gorm.DB) error
+	ID   string
+	Up   func(tx *gorm.DB) error
+	Down func(tx *gorm.DB) error // nil when the migration cannot be reverted
 }
 
+// migrations lists every migration in the order they are applied. Never edit or reorder
+// migrations that have been released; add a new one instead.
 var migrations = []Migration{
-	{
-		ID: "20240101_create_urls_table",
-		Migrate: func(tx *gorm.DB) error {
-			return tx.Exec(`
-				CREATE TABLE IF NOT EXISTS urls (
-					id SERIAL PRIMARY KEY,
-					long_url TEXT NOT NULL,
-					short_url TEXT UNIQUE NOT NULL,
-					created_at TIMESTAMP NOT NULL DEFAULT NOW(),
-					expiry TIMESTAMP,
-					user_id INT REFERENCES users(id) ON DELETE CASCADE,
-					clicks INT NOT NULL DEFAULT 0
-				);
-			`).Error
-		},
-	},
-}
-
-// RunMigrations applies all pending migrations
-func RunMigrations() {
-	for _, migration := range migrations {
-		if err := applyMigration(migration); err != nil {
-			log.Fatalf("Migration failed (ID: %s): %v", migration.ID, err)
-		}
-		log.Printf("Migration applied successfully: %s", migration.ID)
+	createSchema,
+}
+
+// lockID identifies the Postgres advisory lock held while migrating
+const lockID = 0x676f73686f7274 // "goshort"
+
+// schemaMigration records an applied migration
+type schemaMigration struct {
+	ID        string    `gorm:"primaryKey"`
+	AppliedAt time.Time `gorm:"not null"`
+}
+
+// TableName names the table recording applied migrations
+func (schemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// Status describes whether a migration has been applied
+type Status struct {
+	ID        string
+	AppliedAt *time.Time // nil when the migration is pending
+}
+
+// Up applies every pending migration and returns the IDs of those applied
+func Up(db *gorm.DB) ([]string, error) {
+	var applied []string
+	err := withLock(db, func(conn *gorm.DB) error {
+		done, err := appliedMigrations(conn)
+		if err != nil {
+			return err
+		}
+		for _, m := range migrations {
+			if _, ok := done[m.ID]; ok {
+				continue
+			}
+			logger.Info("Applying migration", logger.Fields{"migration": m.ID})
+			err := conn.Transaction(func(tx *gorm.DB) error {
+				if err := m.Up(tx); err != nil {
+					return err
+				}
+				return tx.Create(&schemaMigration{ID: m.ID, AppliedAt: time.Now()}).Error
+			})
+			if err != nil {
+				return fmt.Errorf("migration %s failed: %w", m.ID, err)
+			}
+			applied = append(applied, m.ID)
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// Down reverts the last steps applied migrations and returns the IDs of those reverted
+func Down(db *gorm.DB, steps int) ([]string, error) {
+	var reverted []string
+	err := withLock(db, func(conn *gorm.DB) error {
+		done, err := appliedMigrations(conn)
+		if err != nil {
+			return err
+		}
+		for i := len(migrations) - 1; i >= 0 && len(reverted) < steps; i-- {
+			m := migrations[i]
+			if _, ok := done[m.ID]; !ok {
+				continue
+			}
+			if m.Down == nil {
+				return fmt.Errorf("migration %s cannot be reverted", m.ID)
+			}
+			logger.Info("Reverting migration", logger.Fields{"migration": m.ID})
+			err := conn.Transaction(func(tx *gorm.DB) error {
+				if err := m.Down(tx); err != nil {
+					return err
+				}
+				return tx.Delete(&schemaMigration{ID: m.ID}).Error
+			})
+			if err != nil {
+				return fmt.Errorf("reverting migration %s failed: %w", m.ID, err)
+			}
+			reverted = append(reverted, m.ID)
+		}
+		return nil
+	})
+	return reverted, err
+}
+
+// List returns the status of every migration, in the order they are applied
+func List(db *gorm.DB) ([]Status, error) {
+	done := map[string]time.Time{}
+	if db.Migrator().HasTable(&schemaMigration{}) {
+		var err error
+		if done, err = appliedMigrations(db); err != nil {
+			return nil, err
+		}
+	}
+
+	statuses := make([]Status, 0, len(migrations))
+	for _, m := range migrations {
+		status := Status{ID: m.ID}
+		if appliedAt, ok := done[m.ID]; ok {
+			status.AppliedAt = &appliedAt
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// Pending returns the IDs of the migrations not applied yet
+func Pending(db *gorm.DB) ([]string, error) {
+	statuses, err := List(db)
+	if err != nil {
+		return nil, err
 	}
+	var pending []string
+	for _, status := range statuses {
+		if status.AppliedAt == nil {
+			pending = append(pending, status.ID)
+		}
+	}
+	return pending, nil
+}
+
+// withLock runs fn on a single connection holding the migration lock, so that replicas
+// starting at the same time apply each migration once. SQLite databases are local to one
+// node and rely on SQLite's own locking.
+func withLock(db *gorm.DB, fn func(conn *gorm.DB) error) error {
+	return db.Connection(func(conn *gorm.DB) error {
+		// Start every statement afresh while staying on the pinned connection
+		conn = conn.Session(&gorm.Session{NewDB: true})
+		if conn.Dialector.Name() == "postgres" {
+			// Migrations and waiting for the lock may outlast the configured statement timeout
+			if err := conn.Exec("SET statement_timeout = 0").Error; err != nil {
+				return fmt.Errorf("failed to lift the statement timeout: %w", err)
+			}
+			defer conn.Exec("RESET statement_timeout")
+			if err := conn.Exec("SELECT pg_advisory_lock(?)", lockID).Error; err != nil {
+				return fmt.Errorf("failed to take the migration lock: %w", err)
+			}
+			defer conn.Exec("SELECT pg_advisory_unlock(?)", lockID)
+		}
+		if err := conn.AutoMigrate(&schemaMigration{}); err != nil {
+			return fmt.Errorf("failed to create the schema_migrations table: %w", err)
+		}
+		return fn(conn)
+	})
 }
 
-func applyMigration(migration Migration) error {
-	log.Printf("Applying migration: %s", migration.ID)
-	return db.DB.Transaction(migration.Migrate)
+// appliedMigrations returns when each applied migration was applied, by ID
+func appliedMigrations(db *gorm.DB) (map[string]time.Time, error) {
+	var rows []schemaMigration
+	if err := db.Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to read applied migrations: %w", err)
+	}
+	done := make(map[string]time.Time, len(rows))
+	for _, row := range rows {
+		done[row.ID] = row.AppliedAt
+	}
+	return done, nil
 }
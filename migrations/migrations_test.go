@@ -0,0 +1,93 @@
+package migrations
+
+import (
+	"GoShort/internal/models"
+	"path/filepath"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// openSQLite opens a fresh SQLite database
+func openSQLite(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "goshort.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	assert.NoError(t, err)
+	t.Cleanup(func() {
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+	})
+	return db
+}
+
+func TestUpAndDown(t *testing.T) {
+	db := openSQLite(t)
+
+	pending, err := Pending(db)
+	assert.NoError(t, err)
+	assert.Len(t, pending, len(migrations))
+
+	applied, err := Up(db)
+	assert.NoError(t, err)
+	assert.Len(t, applied, len(migrations))
+	assert.True(t, db.Migrator().HasTable("urls"))
+
+	// Applied migrations are recorded and not run again
+	applied, err = Up(db)
+	assert.NoError(t, err)
+	assert.Empty(t, applied)
+
+	statuses, err := List(db)
+	assert.NoError(t, err)
+	for _, status := range statuses {
+		assert.NotNil(t, status.AppliedAt, status.ID)
+	}
+	pending, err = Pending(db)
+	assert.NoError(t, err)
+	assert.Empty(t, pending)
+
+	reverted, err := Down(db, len(migrations))
+	assert.NoError(t, err)
+	assert.Len(t, reverted, len(migrations))
+	assert.False(t, db.Migrator().HasTable("urls"))
+
+	statuses, err = List(db)
+	assert.NoError(t, err)
+	for _, status := range statuses {
+		assert.Nil(t, status.AppliedAt, status.ID)
+	}
+}
+
+func TestSchemaMatchesModels(t *testing.T) {
+	db := openSQLite(t)
+	_, err := Up(db)
+	assert.NoError(t, err)
+
+	// Every model field needs a column, so model changes must come with a migration
+	for _, model := range []interface{}{&models.URL{}, &models.URLVariant{}, &models.URLSchedule{}, &models.ClickEvent{}, &models.Domain{}} {
+		stmt := &gorm.Statement{DB: db}
+		assert.NoError(t, stmt.Parse(model))
+		for _, field := range stmt.Schema.Fields {
+			if field.DBName != "" {
+				assert.True(t, db.Migrator().HasColumn(model, field.DBName), "%s.%s has no column", stmt.Schema.Table, field.DBName)
+			}
+		}
+	}
+}
+
+func TestUpAdoptsExistingSchema(t *testing.T) {
+	// Databases created by AutoMigrate before versioned migrations are brought under them
+	db := openSQLite(t)
+	assert.NoError(t, db.AutoMigrate(&models.URL{}, &models.URLVariant{}, &models.URLSchedule{}, &models.ClickEvent{}, &models.Domain{}))
+	assert.NoError(t, db.Create(&models.URL{LongURL: "https://example.com", ShortURL: "promo"}).Error)
+
+	_, err := Up(db)
+	assert.NoError(t, err)
+	var count int64
+	assert.NoError(t, db.Model(&models.URL{}).Count(&count).Error)
+	assert.Equal(t, int64(1), count)
+}
@@ -1,31 +1,533 @@
 package config
 
 import (
-	"log"
+	"GoShort/pkg/logger"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/netip"
+	"net/url"
 	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
 
+	"github.com/BurntSushi/toml"
 	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
 )
 
-var configMap map[string]string
+// Config is the complete GoShort configuration. It is read from an optional YAML or TOML
+// file named by CONFIG_FILE, then overridden by environment variables.
+type Config struct {
+	Server    ServerConfig    `yaml:"server" toml:"server"`
+	TLS       TLSConfig       `yaml:"tls" toml:"tls"`
+	Database  DatabaseConfig  `yaml:"database" toml:"database"`
+	Links     LinksConfig     `yaml:"links" toml:"links"`
+	Cache     CacheConfig     `yaml:"cache" toml:"cache"`
+	Scanner   ScannerConfig   `yaml:"scanner" toml:"scanner"`
+	Branding  BrandingConfig  `yaml:"branding" toml:"branding"`
+	Features  FeaturesConfig  `yaml:"features" toml:"features"`
+	Admin     AdminConfig     `yaml:"admin" toml:"admin"`
+	Metrics   MetricsConfig   `yaml:"metrics" toml:"metrics"`
+	Domains   DomainsConfig   `yaml:"domains" toml:"domains"`
+	RateLimit RateLimitConfig `yaml:"rate_limit" toml:"rate_limit"`
+	Log       LogConfig       `yaml:"log" toml:"log"`
+	Tracing   TracingConfig   `yaml:"tracing" toml:"tracing"`
+}
+
+// ServerConfig configures the HTTP listener
+type ServerConfig struct {
+	Addr              string   `yaml:"addr" toml:"addr" env:"LISTEN_ADDR"`
+	ReadHeaderTimeout Duration `yaml:"read_header_timeout" toml:"read_header_timeout" env:"SERVER_READ_HEADER_TIMEOUT"`
+	ReadTimeout       Duration `yaml:"read_timeout" toml:"read_timeout" env:"SERVER_READ_TIMEOUT"`
+	WriteTimeout      Duration `yaml:"write_timeout" toml:"write_timeout" env:"SERVER_WRITE_TIMEOUT"`
+	IdleTimeout       Duration `yaml:"idle_timeout" toml:"idle_timeout" env:"SERVER_IDLE_TIMEOUT"`
+	ShutdownTimeout   Duration `yaml:"shutdown_timeout" toml:"shutdown_timeout" env:"SERVER_SHUTDOWN_TIMEOUT"` // Time allowed to drain requests and flush pending work on shutdown
+}
+
+// TLSConfig configures the optional HTTPS listener. When it is enabled the HTTP listener
+// answers ACME HTTP-01 challenges and redirects everything else to HTTPS.
+type TLSConfig struct {
+	Enabled      bool       `yaml:"enabled" toml:"enabled" env:"TLS_ENABLED"`
+	Addr         string     `yaml:"addr" toml:"addr" env:"TLS_ADDR"`
+	CertFile     string     `yaml:"cert_file" toml:"cert_file" env:"TLS_CERT_FILE"`
+	KeyFile      string     `yaml:"key_file" toml:"key_file" env:"TLS_KEY_FILE"`
+	RedirectHTTP bool       `yaml:"redirect_http" toml:"redirect_http" env:"TLS_REDIRECT_HTTP"` // Redirect plain HTTP requests to HTTPS
+	ACME         ACMEConfig `yaml:"acme" toml:"acme"`
+}
+
+// ACMEConfig configures automatic certificates for the configured hosts and custom domains
+type ACMEConfig struct {
+	Enabled      bool   `yaml:"enabled" toml:"enabled" env:"ACME_ENABLED"`
+	Email        string `yaml:"email" toml:"email" env:"ACME_EMAIL"`
+	DirectoryURL string `yaml:"directory_url" toml:"directory_url" env:"ACME_DIRECTORY_URL"` // Defaults to Let's Encrypt
+	CAFile       string `yaml:"ca_file" toml:"ca_file" env:"ACME_CA_FILE"`                   // Extra CA trusted for the directory, e.g. Pebble's
+	CacheDir     string `yaml:"cache_dir" toml:"cache_dir" env:"ACME_CACHE_DIR"`
+	AcceptTOS    bool   `yaml:"accept_tos" toml:"accept_tos" env:"ACME_ACCEPT_TOS"` // Agree to the CA's terms of service
+}
+
+// DatabaseConfig configures the database connection and its pool
+type DatabaseConfig struct {
+	URL              string   `yaml:"url" toml:"url" env:"DATABASE_URL"`
+	MaxOpenConns     int      `yaml:"max_open_conns" toml:"max_open_conns" env:"DB_MAX_OPEN_CONNS"`
+	MaxIdleConns     int      `yaml:"max_idle_conns" toml:"max_idle_conns" env:"DB_MAX_IDLE_CONNS"`
+	ConnMaxLifetime  Duration `yaml:"conn_max_lifetime" toml:"conn_max_lifetime" env:"DB_CONN_MAX_LIFETIME"`
+	ConnMaxIdleTime  Duration `yaml:"conn_max_idle_time" toml:"conn_max_idle_time" env:"DB_CONN_MAX_IDLE_TIME"`
+	ConnectTimeout   Duration `yaml:"connect_timeout" toml:"connect_timeout" env:"DB_CONNECT_TIMEOUT"`       // How long to keep retrying the first connection
+	StatementTimeout Duration `yaml:"statement_timeout" toml:"statement_timeout" env:"DB_STATEMENT_TIMEOUT"` // Postgres statements running longer are cancelled
+	AutoMigrate      bool     `yaml:"auto_migrate" toml:"auto_migrate" env:"DB_AUTO_MIGRATE"`                // Apply pending migrations on startup
+	Replicas         []string `yaml:"replicas" toml:"replicas" env:"DATABASE_REPLICA_URLS"`                  // Read replicas serving redirect and stats queries
+	ReplicaCheck     Duration `yaml:"replica_check" toml:"replica_check" env:"DB_REPLICA_CHECK_INTERVAL"`    // How often replica health is checked
+}
+
+// LinksConfig limits the lifetime of short links
+type LinksConfig struct {
+	DefaultTTL Duration `yaml:"default_ttl" toml:"default_ttl" env:"LINK_DEFAULT_TTL"` // Expiry given to links created without one
+	MaxTTL     Duration `yaml:"max_ttl" toml:"max_ttl" env:"LINK_MAX_TTL"`             // Latest expiry a link may be given
+}
+
+// CacheConfig configures the in-process cache of link lookups used by redirects
+type CacheConfig struct {
+	Enabled     bool        `yaml:"enabled" toml:"enabled" env:"CACHE_ENABLED"`
+	Size        int         `yaml:"size" toml:"size" env:"CACHE_SIZE"` // Maximum number of cached lookups
+	TTL         Duration    `yaml:"ttl" toml:"ttl" env:"CACHE_TTL"`
+	NegativeTTL Duration    `yaml:"negative_ttl" toml:"negative_ttl" env:"CACHE_NEGATIVE_TTL"` // How long unknown short URLs are remembered
+	Redis       RedisConfig `yaml:"redis" toml:"redis"`
+}
+
+// RedisConfig configures the optional Redis cache shared by every replica
+type RedisConfig struct {
+	URL       string `yaml:"url" toml:"url" env:"REDIS_URL"` // Enables the shared cache when set
+	KeyPrefix string `yaml:"key_prefix" toml:"key_prefix" env:"REDIS_KEY_PREFIX"`
+}
+
+// ScannerConfig configures the malicious URL scanner consulted before links are created
+type ScannerConfig struct {
+	Enabled  bool   `yaml:"enabled" toml:"enabled" env:"SCANNER_ENABLED"`
+	Endpoint string `yaml:"endpoint" toml:"endpoint" env:"SCANNER_ENDPOINT"`
+	APIKey   string `yaml:"api_key" toml:"api_key" env:"SCANNER_API_KEY"`
+	FailOpen bool   `yaml:"fail_open" toml:"fail_open" env:"SCANNER_FAIL_OPEN"` // Accept links when the scanner is unreachable
+}
+
+// BrandingConfig holds the global branding returned to the frontend
+type BrandingConfig struct {
+	Title          string `yaml:"title" toml:"title" env:"BRAND_TITLE"`
+	Description    string `yaml:"description" toml:"description" env:"BRAND_DESCRIPTION"`
+	Keywords       string `yaml:"keywords" toml:"keywords" env:"BRAND_KEYWORDS"`
+	Author         string `yaml:"author" toml:"author" env:"BRAND_AUTHOR"`
+	ThemeColor     string `yaml:"theme_color" toml:"theme_color" env:"BRAND_THEME_COLOR"`
+	LogoText       string `yaml:"logo_text" toml:"logo_text" env:"BRAND_LOGO_TEXT"`
+	PrimaryColor   string `yaml:"primary_color" toml:"primary_color" env:"BRAND_PRIMARY_COLOR"`
+	SecondaryColor string `yaml:"secondary_color" toml:"secondary_color" env:"BRAND_SECONDARY_COLOR"`
+	HeaderTitle    string `yaml:"header_title" toml:"header_title" env:"BRAND_HEADER_TITLE"`
+	FooterText     string `yaml:"footer_text" toml:"footer_text" env:"BRAND_FOOTER_TEXT"`
+	FooterLink     string `yaml:"footer_link" toml:"footer_link" env:"BRAND_FOOTER_LINK"`
+	File           string `yaml:"file" toml:"file" env:"BRANDING_FILE"` // JSON file with per-domain branding
+}
+
+// FeaturesConfig switches optional features on and off
+type FeaturesConfig struct {
+	PreviewMode   bool `yaml:"preview_mode" toml:"preview_mode" env:"PREVIEW_MODE"`         // Show the preview page for every link
+	Analytics     bool `yaml:"analytics" toml:"analytics" env:"FEATURE_ANALYTICS"`          // Record individual click events
+	MetadataFetch bool `yaml:"metadata_fetch" toml:"metadata_fetch" env:"FEATURE_METADATA"` // Fetch destination titles and favicons
+}
+
+// AdminConfig configures access to the admin API
+type AdminConfig struct {
+	Token string `yaml:"token" toml:"token" env:"ADMIN_TOKEN"`
+}
+
+// MetricsConfig configures the Prometheus metrics endpoint
+type MetricsConfig struct {
+	Enabled bool   `yaml:"enabled" toml:"enabled" env:"METRICS_ENABLED"` // Off by default since metrics reveal traffic
+	Token   string `yaml:"token" toml:"token" env:"METRICS_TOKEN"`       // Bearer token scrapers must send, empty leaves /metrics open
+}
+
+// DomainsConfig is the policy for the hosts GoShort answers on
+type DomainsConfig struct {
+	Hosts   []string `yaml:"hosts" toml:"hosts" env:"DOMAIN_HOSTS"`          // Hosts serving the default namespace
+	Strict  bool     `yaml:"strict" toml:"strict" env:"DOMAIN_STRICT"`       // Reject hosts that are neither listed nor registered custom domains
+	BaseURL string   `yaml:"base_url" toml:"base_url" env:"DOMAIN_BASE_URL"` // Public URL of the default namespace, e.g. https://go.example.com, encoded in QR codes
+}
+
+// RateLimitConfig limits how many API requests each client may make
+type RateLimitConfig struct {
+	Enabled           bool     `yaml:"enabled" toml:"enabled" env:"RATE_LIMIT_ENABLED"`
+	RequestsPerMinute int      `yaml:"requests_per_minute" toml:"requests_per_minute" env:"RATE_LIMIT_RPM"`
+	Burst             int      `yaml:"burst" toml:"burst" env:"RATE_LIMIT_BURST"`
+	TrustedProxies    []string `yaml:"trusted_proxies" toml:"trusted_proxies" env:"RATE_LIMIT_TRUSTED_PROXIES"` // Addresses or CIDRs whose X-Forwarded-For / X-Real-IP are believed
+}
+
+// Proxies returns the trusted proxies as prefixes, skipping invalid entries
+func (c RateLimitConfig) Proxies() []netip.Prefix {
+	prefixes := make([]netip.Prefix, 0, len(c.TrustedProxies))
+	for _, proxy := range c.TrustedProxies {
+		if prefix, err := parseProxy(proxy); err == nil {
+			prefixes = append(prefixes, prefix)
+		}
+	}
+	return prefixes
+}
+
+// parseProxy parses a trusted proxy given as an address or a CIDR
+func parseProxy(proxy string) (netip.Prefix, error) {
+	if strings.Contains(proxy, "/") {
+		prefix, err := netip.ParsePrefix(proxy)
+		return prefix.Masked(), err
+	}
+	addr, err := netip.ParseAddr(proxy)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+// LogConfig configures logging
+type LogConfig struct {
+	Level  string `yaml:"level" toml:"level" env:"LOG_LEVEL"`
+	Format string `yaml:"format" toml:"format" env:"LOG_FORMAT"` // text or json
+}
+
+// logLevels lists the accepted log levels
+var logLevels = []string{"debug", "info", "warn", "warning", "error"}
+
+// logFormats lists the accepted log formats
+var logFormats = []string{"text", "json"}
+
+// TracingConfig configures OpenTelemetry tracing
+type TracingConfig struct {
+	Exporter    string  `yaml:"exporter" toml:"exporter" env:"TRACING_EXPORTER"`             // "none", "otlp" or "stdout"
+	Endpoint    string  `yaml:"endpoint" toml:"endpoint" env:"TRACING_ENDPOINT"`             // OTLP/HTTP collector URL, defaults to OTEL_EXPORTER_OTLP_ENDPOINT
+	ServiceName string  `yaml:"service_name" toml:"service_name" env:"TRACING_SERVICE_NAME"` // Service name spans are reported under
+	SampleRatio float64 `yaml:"sample_ratio" toml:"sample_ratio" env:"TRACING_SAMPLE_RATIO"` // Fraction of new traces recorded
+}
+
+// tracingExporters lists the accepted tracing exporters
+var tracingExporters = []string{"none", "otlp", "stdout"}
+
+// Duration is a time.Duration written as a string such as "30s" or "72h"
+type Duration time.Duration
+
+// UnmarshalText parses a duration string
+func (d *Duration) UnmarshalText(text []byte) error {
+	parsed, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// MarshalText formats the duration as a string
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
 
-// Load loads environment variables from a `.env` file
-func Load() {
+// current holds the configuration in use
+var current atomic.Pointer[Config]
+
+// Default returns the configuration used when nothing is set
+func Default() *Config {
+	return &Config{
+		Server: ServerConfig{
+			Addr:              ":8080",
+			ReadHeaderTimeout: Duration(5 * time.Second),
+			ReadTimeout:       Duration(30 * time.Second),
+			WriteTimeout:      Duration(30 * time.Second),
+			IdleTimeout:       Duration(2 * time.Minute),
+			ShutdownTimeout:   Duration(15 * time.Second),
+		},
+		TLS: TLSConfig{
+			Addr:         ":8443",
+			RedirectHTTP: true,
+			ACME:         ACMEConfig{CacheDir: "certs"},
+		},
+		Database: DatabaseConfig{
+			MaxOpenConns:     25,
+			MaxIdleConns:     5,
+			ConnMaxLifetime:  Duration(30 * time.Minute),
+			ConnMaxIdleTime:  Duration(5 * time.Minute),
+			ConnectTimeout:   Duration(time.Minute),
+			StatementTimeout: Duration(30 * time.Second),
+			AutoMigrate:      true,
+			ReplicaCheck:     Duration(10 * time.Second),
+		},
+		Cache: CacheConfig{
+			Enabled:     true,
+			Size:        10000,
+			TTL:         Duration(5 * time.Minute),
+			NegativeTTL: Duration(30 * time.Second),
+			Redis:       RedisConfig{KeyPrefix: "goshort:"},
+		},
+		Features:  FeaturesConfig{Analytics: true, MetadataFetch: true},
+		RateLimit: RateLimitConfig{RequestsPerMinute: 60, Burst: 20},
+		Log:       LogConfig{Level: "info", Format: "text"},
+		Tracing:   TracingConfig{Exporter: "none", ServiceName: "goshort", SampleRatio: 1},
+	}
+}
+
+// Load reads the configuration from a `.env` file, the file named by CONFIG_FILE and the
+// environment, validates it and makes it the current configuration
+func Load() (*Config, error) {
 	if err := godotenv.Load(); err != nil {
-		log.Println("No .env file found, falling back to system environment variables")
+		logger.Info("No .env file found, falling back to system environment variables", nil)
+	}
+
+	cfg := Default()
+	if path := os.Getenv("CONFIG_FILE"); path != "" {
+		if err := cfg.LoadFile(path); err != nil {
+			return nil, err
+		}
+	}
+	if err := cfg.ApplyEnv(); err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	Set(cfg)
+	return cfg, nil
+}
+
+// Current returns the configuration in use, or the defaults before one is loaded
+func Current() *Config {
+	if cfg := current.Load(); cfg != nil {
+		return cfg
 	}
+	return Default()
+}
+
+// Set makes cfg the current configuration
+func Set(cfg *Config) {
+	current.Store(cfg)
+}
+
+// LoadFile reads a YAML or TOML configuration file, chosen by its extension, over cfg.
+// Unknown keys are rejected so that typos do not go unnoticed.
+func (cfg *Config) LoadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("failed to parse config file %s: %w", path, err)
+		}
+	case ".toml":
+		meta, err := toml.Decode(string(data), cfg)
+		if err != nil {
+			return fmt.Errorf("failed to parse config file %s: %w", path, err)
+		}
+		if undecoded := meta.Undecoded(); len(undecoded) > 0 {
+			return fmt.Errorf("failed to parse config file %s: unknown key %s", path, undecoded[0])
+		}
+	default:
+		return fmt.Errorf("unsupported config file format %q, use .yaml or .toml", filepath.Ext(path))
+	}
+	return nil
+}
+
+// ApplyEnv overrides cfg with the environment variables named by the env struct tags.
+// PORT is still honoured as a shorthand for LISTEN_ADDR.
+func (cfg *Config) ApplyEnv() error {
+	if port := os.Getenv("PORT"); port != "" && os.Getenv("LISTEN_ADDR") == "" {
+		cfg.Server.Addr = ":" + port
+	}
+	return applyEnv(reflect.ValueOf(cfg).Elem())
+}
+
+// applyEnv sets the tagged fields of the struct v from the environment
+func applyEnv(v reflect.Value) error {
+	var errs []error
+	for i := 0; i < v.NumField(); i++ {
+		field, value := v.Type().Field(i), v.Field(i)
+		if field.Type.Kind() == reflect.Struct {
+			errs = append(errs, applyEnv(value))
+			continue
+		}
 
-	configMap = map[string]string{
-		"DATABASE_URL": os.Getenv("DATABASE_URL"),
-		"PORT":         os.Getenv("PORT"),
+		name := field.Tag.Get("env")
+		raw, ok := os.LookupEnv(name)
+		if name == "" || !ok || raw == "" {
+			continue
+		}
+
+		switch field.Type {
+		case reflect.TypeOf([]string(nil)):
+			var values []string
+			for _, v := range strings.Split(raw, ",") {
+				if v = strings.TrimSpace(v); v != "" {
+					values = append(values, v)
+				}
+			}
+			value.Set(reflect.ValueOf(values))
+		case reflect.TypeOf(Duration(0)):
+			var d Duration
+			if err := d.UnmarshalText([]byte(raw)); err != nil {
+				errs = append(errs, fmt.Errorf("%s: invalid duration %q", name, raw))
+				continue
+			}
+			value.Set(reflect.ValueOf(d))
+		default:
+			switch field.Type.Kind() {
+			case reflect.String:
+				value.SetString(raw)
+			case reflect.Bool:
+				b, err := strconv.ParseBool(raw)
+				if err != nil {
+					errs = append(errs, fmt.Errorf("%s: invalid boolean %q", name, raw))
+					continue
+				}
+				value.SetBool(b)
+			case reflect.Int:
+				n, err := strconv.Atoi(raw)
+				if err != nil {
+					errs = append(errs, fmt.Errorf("%s: invalid integer %q", name, raw))
+					continue
+				}
+				value.SetInt(int64(n))
+			case reflect.Float64:
+				f, err := strconv.ParseFloat(raw, 64)
+				if err != nil {
+					errs = append(errs, fmt.Errorf("%s: invalid number %q", name, raw))
+					continue
+				}
+				value.SetFloat(f)
+			}
+		}
 	}
+	return errors.Join(errs...)
 }
 
-// Get retrieves a configuration value by key
-func Get(key string) string {
-	value, exists := configMap[key]
-	if !exists {
-		log.Fatalf("Configuration key %s not found", key)
+// Validate checks the configuration and reports every problem found at once
+func (cfg *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	_, _, err := net.SplitHostPort(cfg.Server.Addr)
+	check(err == nil, "server.addr: invalid listen address %q", cfg.Server.Addr)
+	check(cfg.Server.ReadHeaderTimeout >= 0, "server.read_header_timeout: must not be negative")
+	check(cfg.Server.ReadTimeout >= 0, "server.read_timeout: must not be negative")
+	check(cfg.Server.WriteTimeout >= 0, "server.write_timeout: must not be negative")
+	check(cfg.Server.IdleTimeout >= 0, "server.idle_timeout: must not be negative")
+	check(cfg.Server.ShutdownTimeout > 0, "server.shutdown_timeout: must be positive")
+
+	if cfg.TLS.Enabled {
+		_, _, err := net.SplitHostPort(cfg.TLS.Addr)
+		check(err == nil, "tls.addr: invalid listen address %q", cfg.TLS.Addr)
+		check(cfg.TLS.Addr != cfg.Server.Addr, "tls.addr: must differ from server.addr")
+		check((cfg.TLS.CertFile == "") == (cfg.TLS.KeyFile == ""), "tls.cert_file: cert_file and key_file must be set together")
+		check(cfg.TLS.CertFile != "" || cfg.TLS.ACME.Enabled, "tls: a cert_file and key_file or ACME is required when TLS is enabled")
+		for _, path := range []string{cfg.TLS.CertFile, cfg.TLS.KeyFile} {
+			if path != "" {
+				_, err := os.Stat(path)
+				check(err == nil, "tls: %v", err)
+			}
+		}
+	}
+	if cfg.TLS.Enabled && cfg.TLS.ACME.Enabled {
+		acme := cfg.TLS.ACME
+		check(acme.AcceptTOS, "tls.acme.accept_tos: the CA's terms of service must be accepted to use ACME")
+		check(acme.CacheDir != "", "tls.acme.cache_dir: required so certificates survive restarts")
+		if acme.DirectoryURL != "" {
+			directory, err := url.Parse(acme.DirectoryURL)
+			check(err == nil && directory.Scheme == "https" && directory.Host != "",
+				"tls.acme.directory_url: must be an https URL")
+		}
+		if acme.CAFile != "" {
+			_, err := os.Stat(acme.CAFile)
+			check(err == nil, "tls.acme.ca_file: %v", err)
+		}
+	}
+
+	check(cfg.Database.URL != "", "database.url: DATABASE_URL is required")
+	check(cfg.Database.MaxOpenConns >= 0, "database.max_open_conns: must not be negative")
+	check(cfg.Database.MaxIdleConns >= 0, "database.max_idle_conns: must not be negative")
+	check(cfg.Database.MaxOpenConns == 0 || cfg.Database.MaxIdleConns <= cfg.Database.MaxOpenConns,
+		"database.max_idle_conns: must not exceed max_open_conns")
+	check(cfg.Database.ConnMaxLifetime >= 0, "database.conn_max_lifetime: must not be negative")
+	check(cfg.Database.ConnMaxIdleTime >= 0, "database.conn_max_idle_time: must not be negative")
+	check(cfg.Database.ConnectTimeout >= 0, "database.connect_timeout: must not be negative")
+	check(cfg.Database.StatementTimeout >= 0, "database.statement_timeout: must not be negative")
+	if len(cfg.Database.Replicas) > 0 {
+		check(!strings.HasPrefix(cfg.Database.URL, "sqlite://"), "database.replicas: read replicas require Postgres")
+		check(cfg.Database.ReplicaCheck > 0, "database.replica_check: must be positive when replicas are configured")
+		for _, replica := range cfg.Database.Replicas {
+			check(!strings.HasPrefix(replica, "sqlite://"), "database.replicas: read replicas must be Postgres connection strings")
+		}
+	}
+
+	check(cfg.Links.DefaultTTL >= 0, "links.default_ttl: must not be negative")
+	check(cfg.Links.MaxTTL >= 0, "links.max_ttl: must not be negative")
+	check(cfg.Links.MaxTTL == 0 || cfg.Links.DefaultTTL <= cfg.Links.MaxTTL, "links.default_ttl: must not exceed max_ttl")
+
+	if cfg.Cache.Enabled {
+		check(cfg.Cache.Size > 0, "cache.size: must be positive when the cache is enabled")
+		check(cfg.Cache.TTL > 0, "cache.ttl: must be positive when the cache is enabled")
+		check(cfg.Cache.NegativeTTL >= 0, "cache.negative_ttl: must not be negative")
+		if cfg.Cache.Redis.URL != "" {
+			redisURL, err := url.Parse(cfg.Cache.Redis.URL)
+			check(err == nil && (redisURL.Scheme == "redis" || redisURL.Scheme == "rediss" || redisURL.Scheme == "unix"),
+				"cache.redis.url: must be a redis://, rediss:// or unix:// URL")
+		}
+	}
+
+	if cfg.Scanner.Enabled {
+		endpoint, err := url.Parse(cfg.Scanner.Endpoint)
+		check(err == nil && (endpoint.Scheme == "http" || endpoint.Scheme == "https") && endpoint.Host != "",
+			"scanner.endpoint: a valid http(s) URL is required when the scanner is enabled")
+		check(cfg.Scanner.APIKey != "", "scanner.api_key: required when the scanner is enabled")
 	}
-	return value
+
+	for _, host := range cfg.Domains.Hosts {
+		check(host != "" && !strings.ContainsAny(host, "/: "), "domains.hosts: invalid host %q", host)
+	}
+	if cfg.Domains.BaseURL != "" {
+		base, err := url.Parse(cfg.Domains.BaseURL)
+		check(err == nil && (base.Scheme == "http" || base.Scheme == "https") && base.Host != "" && (base.Path == "" || base.Path == "/") && base.RawQuery == "",
+			"domains.base_url: must be an http(s) URL without a path")
+	}
+
+	if cfg.RateLimit.Enabled {
+		check(cfg.RateLimit.RequestsPerMinute > 0, "rate_limit.requests_per_minute: must be positive when rate limiting is enabled")
+		check(cfg.RateLimit.Burst > 0, "rate_limit.burst: must be positive when rate limiting is enabled")
+	}
+	for _, proxy := range cfg.RateLimit.TrustedProxies {
+		_, err := parseProxy(proxy)
+		check(err == nil, "rate_limit.trusted_proxies: invalid address or CIDR %q", proxy)
+	}
+
+	check(slices.Contains(logLevels, strings.ToLower(cfg.Log.Level)), "log.level: must be one of %s", strings.Join(logLevels, ", "))
+	check(slices.Contains(logFormats, cfg.Log.Format), "log.format: must be one of %s", strings.Join(logFormats, ", "))
+
+	check(slices.Contains(tracingExporters, cfg.Tracing.Exporter), "tracing.exporter: must be one of %s", strings.Join(tracingExporters, ", "))
+	check(cfg.Tracing.SampleRatio >= 0 && cfg.Tracing.SampleRatio <= 1, "tracing.sample_ratio: must be between 0 and 1")
+	if cfg.Tracing.Endpoint != "" {
+		endpoint, err := url.Parse(cfg.Tracing.Endpoint)
+		check(err == nil && (endpoint.Scheme == "http" || endpoint.Scheme == "https") && endpoint.Host != "",
+			"tracing.endpoint: must be an http(s) URL")
+	}
+
+	if cfg.Branding.File != "" {
+		_, err := os.Stat(cfg.Branding.File)
+		check(err == nil, "branding.file: %v", err)
+	}
+
+	return errors.Join(errs...)
 }
@@ -0,0 +1,193 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// writeFile writes a config file named name into a temporary directory
+func writeFile(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	assert.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	return path
+}
+
+// clearEnv blanks every variable ApplyEnv reads for the duration of the test, since empty
+// variables are ignored
+func clearEnv(t *testing.T) {
+	t.Setenv("PORT", "")
+	t.Setenv("CONFIG_FILE", "")
+	var clear func(typ reflect.Type)
+	clear = func(typ reflect.Type) {
+		for i := 0; i < typ.NumField(); i++ {
+			field := typ.Field(i)
+			if field.Type.Kind() == reflect.Struct {
+				clear(field.Type)
+			} else if name := field.Tag.Get("env"); name != "" {
+				t.Setenv(name, "")
+			}
+		}
+	}
+	clear(reflect.TypeOf(Config{}))
+}
+
+func TestLoadFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"config.yaml", `
+server:
+  addr: ":9090"
+database:
+  url: postgres://localhost/goshort
+  max_open_conns: 50
+links:
+  default_ttl: 720h
+branding:
+  title: Example Links
+features:
+  analytics: false
+`},
+		{"config.toml", `
+[server]
+addr = ":9090"
+
+[database]
+url = "postgres://localhost/goshort"
+max_open_conns = 50
+
+[links]
+default_ttl = "720h"
+
+[branding]
+title = "Example Links"
+
+[features]
+analytics = false
+`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Default()
+			assert.NoError(t, cfg.LoadFile(writeFile(t, tt.name, tt.content)))
+			assert.Equal(t, ":9090", cfg.Server.Addr)
+			assert.Equal(t, "postgres://localhost/goshort", cfg.Database.URL)
+			assert.Equal(t, 50, cfg.Database.MaxOpenConns)
+			assert.Equal(t, 5, cfg.Database.MaxIdleConns, "Defaults should be kept for missing keys")
+			assert.Equal(t, Duration(720*time.Hour), cfg.Links.DefaultTTL)
+			assert.Equal(t, "Example Links", cfg.Branding.Title)
+			assert.False(t, cfg.Features.Analytics)
+			assert.True(t, cfg.Features.MetadataFetch)
+		})
+	}
+}
+
+func TestLoadFileRejectsUnknownKeys(t *testing.T) {
+	cfg := Default()
+	assert.Error(t, cfg.LoadFile(writeFile(t, "config.yaml", "server:\n  adr: \":9090\"\n")))
+	assert.Error(t, cfg.LoadFile(writeFile(t, "config.toml", "[server]\nadr = \":9090\"\n")))
+	assert.Error(t, cfg.LoadFile(writeFile(t, "config.json", "{}")))
+}
+
+func TestApplyEnv(t *testing.T) {
+	clearEnv(t)
+	t.Setenv("DATABASE_URL", "postgres://db/goshort")
+	t.Setenv("PORT", "3000")
+	t.Setenv("DB_MAX_IDLE_CONNS", "2")
+	t.Setenv("DATABASE_REPLICA_URLS", "postgres://replica1/goshort, postgres://replica2/goshort")
+	t.Setenv("LINK_MAX_TTL", "8760h")
+	t.Setenv("PREVIEW_MODE", "true")
+	t.Setenv("BRAND_TITLE", "From Env")
+	t.Setenv("TRACING_SAMPLE_RATIO", "0.25")
+	t.Setenv("LOG_FORMAT", "json")
+
+	cfg := Default()
+	cfg.Branding.Title = "From File"
+	assert.NoError(t, cfg.ApplyEnv())
+	assert.Equal(t, "postgres://db/goshort", cfg.Database.URL)
+	assert.Equal(t, ":3000", cfg.Server.Addr)
+	assert.Equal(t, 2, cfg.Database.MaxIdleConns)
+	assert.Equal(t, []string{"postgres://replica1/goshort", "postgres://replica2/goshort"}, cfg.Database.Replicas)
+	assert.Equal(t, Duration(8760*time.Hour), cfg.Links.MaxTTL)
+	assert.True(t, cfg.Features.PreviewMode)
+	assert.Equal(t, "From Env", cfg.Branding.Title)
+	assert.Equal(t, 0.25, cfg.Tracing.SampleRatio)
+	assert.Equal(t, "json", cfg.Log.Format)
+
+	t.Setenv("LISTEN_ADDR", "127.0.0.1:8080")
+	assert.NoError(t, cfg.ApplyEnv())
+	assert.Equal(t, "127.0.0.1:8080", cfg.Server.Addr)
+}
+
+func TestApplyEnvAggregatesErrors(t *testing.T) {
+	clearEnv(t)
+	t.Setenv("DB_MAX_OPEN_CONNS", "many")
+	t.Setenv("LINK_DEFAULT_TTL", "forever")
+	t.Setenv("SCANNER_ENABLED", "maybe")
+	t.Setenv("TRACING_SAMPLE_RATIO", "most")
+
+	err := Default().ApplyEnv()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "DB_MAX_OPEN_CONNS")
+	assert.Contains(t, err.Error(), "LINK_DEFAULT_TTL")
+	assert.Contains(t, err.Error(), "SCANNER_ENABLED")
+	assert.Contains(t, err.Error(), "TRACING_SAMPLE_RATIO")
+}
+
+func TestValidate(t *testing.T) {
+	cfg := Default()
+	cfg.Database.URL = "postgres://localhost/goshort"
+	assert.NoError(t, cfg.Validate())
+
+	cfg = Default()
+	cfg.Server.Addr = "8080"
+	cfg.Database.MaxOpenConns = 2
+	cfg.Links.DefaultTTL = Duration(48 * time.Hour)
+	cfg.Links.MaxTTL = Duration(24 * time.Hour)
+	cfg.Scanner.Enabled = true
+	cfg.Log.Format = "logfmt"
+	cfg.RateLimit.TrustedProxies = []string{"10.0.0.0/8", "nginx"}
+	cfg.Domains.BaseURL = "https://go.example.com/links"
+
+	err := cfg.Validate()
+	assert.Error(t, err)
+	messages := strings.Split(err.Error(), "\n")
+	assert.Len(t, messages, 9)
+	assert.Contains(t, err.Error(), "server.addr")
+	assert.Contains(t, err.Error(), "database.url")
+	assert.Contains(t, err.Error(), "database.max_idle_conns")
+	assert.Contains(t, err.Error(), "links.default_ttl")
+	assert.Contains(t, err.Error(), "scanner.endpoint")
+	assert.Contains(t, err.Error(), "scanner.api_key")
+	assert.Contains(t, err.Error(), "log.format")
+	assert.Contains(t, err.Error(), `rate_limit.trusted_proxies: invalid address or CIDR "nginx"`)
+	assert.Contains(t, err.Error(), "domains.base_url")
+}
+
+func TestValidateTLS(t *testing.T) {
+	cfg := Default()
+	cfg.Database.URL = "postgres://localhost/goshort"
+	cfg.TLS.Enabled = true
+	err := cfg.Validate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "a cert_file and key_file or ACME is required")
+
+	cfg.TLS.ACME.Enabled = true
+	cfg.TLS.ACME.DirectoryURL = "http://localhost:14000/dir"
+	err = cfg.Validate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "tls.acme.accept_tos")
+	assert.Contains(t, err.Error(), "tls.acme.directory_url")
+
+	cfg.TLS.ACME.AcceptTOS = true
+	cfg.TLS.ACME.DirectoryURL = "https://localhost:14000/dir"
+	assert.NoError(t, cfg.Validate())
+}
@@ -0,0 +1,142 @@
+package config
+
+import (
+	"GoShort/pkg/logger"
+	"context"
+	"os"
+	"os/signal"
+	"reflect"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// reloadable lists the sections of Config that can change without a restart
+var reloadable = map[string]bool{
+	"Branding":  true,
+	"Domains":   true,
+	"RateLimit": true,
+	"Log":       true,
+}
+
+// reloadMu serialises reloads
+var reloadMu sync.Mutex
+
+// Reload re-reads the config file and the environment and atomically swaps in the sections
+// that can change at runtime: branding, domain policy, rate limits and log level. Changes to
+// other sections are reported and only take effect after a restart. When the new
+// configuration is invalid the current one is kept.
+func Reload() (*Config, error) {
+	reloadMu.Lock()
+	defer reloadMu.Unlock()
+
+	next := Default()
+	if path := os.Getenv("CONFIG_FILE"); path != "" {
+		if err := next.LoadFile(path); err != nil {
+			return nil, err
+		}
+	}
+	if err := next.ApplyEnv(); err != nil {
+		return nil, err
+	}
+	if err := next.Validate(); err != nil {
+		return nil, err
+	}
+
+	// Build a new snapshot rather than editing the current one, which requests may be reading
+	merged := *Current()
+	var restart []string
+	mergedValue, nextValue := reflect.ValueOf(&merged).Elem(), reflect.ValueOf(next).Elem()
+	for i := 0; i < mergedValue.NumField(); i++ {
+		field := mergedValue.Type().Field(i)
+		if reloadable[field.Name] {
+			mergedValue.Field(i).Set(nextValue.Field(i))
+		} else if !reflect.DeepEqual(mergedValue.Field(i).Interface(), nextValue.Field(i).Interface()) {
+			restart = append(restart, field.Tag.Get("yaml"))
+		}
+	}
+	if len(restart) > 0 {
+		logger.Warn("Configuration changes require a restart to take effect", logger.Fields{"sections": strings.Join(restart, ", ")})
+	}
+
+	Set(&merged)
+	return &merged, nil
+}
+
+// Watch reloads the configuration when the process receives SIGHUP or when the config file
+// or the branding file changes, checking their modification times every interval. onReload is
+// called with each configuration swapped in. Watch returns when ctx is done.
+func Watch(ctx context.Context, interval time.Duration, onReload func(*Config)) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	path := os.Getenv("CONFIG_FILE")
+	lastModified := modTime(path)
+	brandingPath := Current().Branding.File
+	lastBranding := modTime(brandingPath)
+
+	reload := func(reason string) {
+		cfg, err := Reload()
+		if err != nil {
+			logger.Error("Failed to reload configuration, keeping the current one", logger.Fields{"reason": reason, "error": err})
+			return
+		}
+		logger.Info("Configuration reloaded", logger.Fields{"reason": reason})
+		brandingPath = cfg.Branding.File
+		lastBranding = modTime(brandingPath)
+		if onReload != nil {
+			onReload(cfg)
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			reload("SIGHUP")
+		case <-ticker.C:
+			if modified := modTime(path); !modified.Equal(lastModified) {
+				lastModified = modified
+				reload("config file change")
+			} else if modified := modTime(brandingPath); !modified.Equal(lastBranding) {
+				lastBranding = modified
+				reload("branding file change")
+			}
+		}
+	}
+}
+
+// modTime returns the modification time of path, or the zero time when it cannot be read
+func modTime(path string) time.Time {
+	if path == "" {
+		return time.Time{}
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
+
+// contextKey is the type of the context key holding a configuration snapshot
+type contextKey struct{}
+
+// WithContext returns a copy of ctx carrying cfg
+func WithContext(ctx context.Context, cfg *Config) context.Context {
+	return context.WithValue(ctx, contextKey{}, cfg)
+}
+
+// FromContext returns the configuration snapshot carried by ctx, or the current
+// configuration when there is none
+func FromContext(ctx context.Context) *Config {
+	if cfg, ok := ctx.Value(contextKey{}).(*Config); ok {
+		return cfg
+	}
+	return Current()
+}
@@ -0,0 +1,113 @@
+package config
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// startConfig loads path as the current configuration
+func startConfig(t *testing.T, path string) {
+	clearEnv(t)
+	t.Setenv("CONFIG_FILE", path)
+	t.Cleanup(func() { Set(Default()) })
+
+	cfg := Default()
+	assert.NoError(t, cfg.LoadFile(path))
+	Set(cfg)
+}
+
+func TestReloadSwapsRuntimeSections(t *testing.T) {
+	path := writeFile(t, "config.yaml", "server:\n  addr: \":8080\"\ndatabase:\n  url: postgres://localhost/goshort\nbranding:\n  title: Before\n")
+	startConfig(t, path)
+	before := Current()
+
+	assert.NoError(t, os.WriteFile(path, []byte(`
+server:
+  addr: ":9090"
+database:
+  url: postgres://localhost/goshort
+branding:
+  title: After
+rate_limit:
+  enabled: true
+log:
+  level: debug
+`), 0o644))
+
+	cfg, err := Reload()
+	assert.NoError(t, err)
+	assert.Same(t, cfg, Current())
+	assert.Equal(t, "After", cfg.Branding.Title)
+	assert.True(t, cfg.RateLimit.Enabled)
+	assert.Equal(t, "debug", cfg.Log.Level)
+	assert.Equal(t, ":8080", cfg.Server.Addr, "Structural settings need a restart")
+
+	// The previous snapshot is left untouched for requests still using it
+	assert.Equal(t, "Before", before.Branding.Title)
+}
+
+func TestReloadKeepsConfigWhenInvalid(t *testing.T) {
+	path := writeFile(t, "config.yaml", "database:\n  url: postgres://localhost/goshort\nbranding:\n  title: Before\n")
+	startConfig(t, path)
+	before := Current()
+
+	assert.NoError(t, os.WriteFile(path, []byte("database:\n  url: postgres://localhost/goshort\nlog:\n  level: loud\n"), 0o644))
+	_, err := Reload()
+	assert.Error(t, err)
+	assert.Same(t, before, Current())
+}
+
+func TestWatchReloadsOnFileChange(t *testing.T) {
+	path := writeFile(t, "config.yaml", "database:\n  url: postgres://localhost/goshort\nbranding:\n  title: Before\n")
+	startConfig(t, path)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	reloaded := make(chan *Config, 1)
+	go Watch(ctx, 10*time.Millisecond, func(cfg *Config) { reloaded <- cfg })
+
+	// Make sure the modification time changes even on coarse-grained filesystems
+	time.Sleep(20 * time.Millisecond)
+	assert.NoError(t, os.WriteFile(path, []byte("database:\n  url: postgres://localhost/goshort\nbranding:\n  title: After\n"), 0o644))
+	assert.NoError(t, os.Chtimes(path, time.Now().Add(time.Second), time.Now().Add(time.Second)))
+
+	select {
+	case cfg := <-reloaded:
+		assert.Equal(t, "After", cfg.Branding.Title)
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected the configuration to be reloaded")
+	}
+}
+
+func TestWatchReloadsOnBrandingFileChange(t *testing.T) {
+	branding := writeFile(t, "branding.json", `{}`)
+	path := writeFile(t, "config.yaml", "database:\n  url: postgres://localhost/goshort\nbranding:\n  file: "+branding+"\n")
+	startConfig(t, path)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	reloaded := make(chan *Config, 1)
+	go Watch(ctx, 10*time.Millisecond, func(cfg *Config) { reloaded <- cfg })
+
+	time.Sleep(20 * time.Millisecond)
+	assert.NoError(t, os.WriteFile(branding, []byte(`{"go.example.com":{"title":"Example"}}`), 0o644))
+	assert.NoError(t, os.Chtimes(branding, time.Now().Add(time.Second), time.Now().Add(time.Second)))
+
+	select {
+	case cfg := <-reloaded:
+		assert.Equal(t, branding, cfg.Branding.File)
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected the configuration to be reloaded")
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	assert.NotNil(t, FromContext(context.Background()))
+
+	cfg := Default()
+	assert.Same(t, cfg, FromContext(WithContext(context.Background(), cfg)))
+}
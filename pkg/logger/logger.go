@@ -1,37 +1,142 @@
 package logger
 
 import (
+	"context"
+	"fmt"
+	"io"
 	"os"
 
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
 )
 
-var log *logrus.Logger
+// Fields are the structured fields attached to a log message
+type Fields = map[string]interface{}
+
+// log is usable before Init so that configuration problems can be reported
+var log = newLogger()
+
+// newLogger returns a logger writing text at the info level to stderr, which keeps stdout
+// free for commands such as `goshort export`
+func newLogger() *logrus.Logger {
+	l := logrus.New()
+	l.SetOutput(os.Stderr)
+	l.SetLevel(logrus.InfoLevel)
+	l.SetFormatter(textFormatter())
+	return l
+}
+
+func textFormatter() logrus.Formatter {
+	return &logrus.TextFormatter{FullTimestamp: true}
+}
 
 // Init initializes the logger
 func Init() {
-	log = logrus.New()
-	log.SetOutput(os.Stdout)
-	log.SetLevel(logrus.InfoLevel)
-	log.SetFormatter(&logrus.TextFormatter{
-		FullTimestamp: true,
-	})
+	log = newLogger()
 }
 
-// Info logs an informational message
-func Info(message string, fields map[string]interface{}) {
-	if fields != nil {
-		log.WithFields(fields).Info(message)
-	} else {
-		log.Info(message)
+// SetLevel changes the minimum level of logged messages
+func SetLevel(level string) error {
+	parsed, err := logrus.ParseLevel(level)
+	if err != nil {
+		return err
+	}
+	log.SetLevel(parsed)
+	return nil
+}
+
+// SetFormat switches between human-readable text and one JSON object per line
+func SetFormat(format string) error {
+	switch format {
+	case "text":
+		log.SetFormatter(textFormatter())
+	case "json":
+		log.SetFormatter(&logrus.JSONFormatter{DisableHTMLEscape: true})
+	default:
+		return fmt.Errorf("unknown log format %q", format)
 	}
+	return nil
+}
+
+// SetOutput changes where messages are written
+func SetOutput(w io.Writer) {
+	log.SetOutput(w)
+}
+
+// DebugEnabled reports whether debug messages are logged, to skip building costly ones
+func DebugEnabled() bool {
+	return log.IsLevelEnabled(logrus.DebugLevel)
+}
+
+// Debug logs a debugging message
+func Debug(message string, fields Fields) {
+	DebugContext(context.Background(), message, fields)
+}
+
+// Info logs an informational message
+func Info(message string, fields Fields) {
+	InfoContext(context.Background(), message, fields)
+}
+
+// Warn logs a warning
+func Warn(message string, fields Fields) {
+	WarnContext(context.Background(), message, fields)
 }
 
 // Error logs an error message
-func Error(message string, fields map[string]interface{}) {
+func Error(message string, fields Fields) {
+	ErrorContext(context.Background(), message, fields)
+}
+
+// Fatal logs an error message and exits
+func Fatal(message string, fields Fields) {
+	entry(context.Background(), fields).Fatal(message)
+}
+
+// DebugContext logs a debugging message with the request ID and trace of ctx
+func DebugContext(ctx context.Context, message string, fields Fields) {
+	entry(ctx, fields).Debug(message)
+}
+
+// InfoContext logs an informational message with the request ID and trace of ctx
+func InfoContext(ctx context.Context, message string, fields Fields) {
+	entry(ctx, fields).Info(message)
+}
+
+// WarnContext logs a warning with the request ID and trace of ctx
+func WarnContext(ctx context.Context, message string, fields Fields) {
+	entry(ctx, fields).Warn(message)
+}
+
+// ErrorContext logs an error message with the request ID and trace of ctx
+func ErrorContext(ctx context.Context, message string, fields Fields) {
+	entry(ctx, fields).Error(message)
+}
+
+// entry attaches fields, and the request ID and trace ID carried by ctx, to a message
+func entry(ctx context.Context, fields Fields) *logrus.Entry {
+	e := logrus.NewEntry(log)
+	if id := RequestID(ctx); id != "" {
+		e = e.WithField("request_id", id)
+	}
+	if span := trace.SpanContextFromContext(ctx); span.IsValid() {
+		e = e.WithField("trace_id", span.TraceID().String())
+	}
 	if fields != nil {
-		log.WithFields(fields).Error(message)
-	} else {
-		log.Error(message)
+		e = e.WithFields(fields)
 	}
+	return e
+}
+
+type requestIDKey struct{}
+
+// WithRequestID returns a copy of ctx carrying the ID of the request being served
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID returns the request ID carried by ctx, or "" outside a request
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
 }
@@ -0,0 +1,92 @@
+package logger
+
+import (
+	"net/url"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// Redacted replaces the values of sensitive query parameters in logged URLs
+const Redacted = "REDACTED"
+
+// sensitiveParams are matched case-insensitively against whole query parameter names and
+// their delimiter-bounded suffixes, so that e.g. access_token, api_key, clientSecret and
+// X-Amz-Signature are redacted while author, design and monkey are not
+var sensitiveParams = []string{"token", "key", "apikey", "secret", "password", "passwd", "pwd", "auth", "session", "session_id", "sessionid", "sig", "signature", "credential", "credentials"}
+
+// sensitiveNames are only redacted as whole parameter names, since an OAuth code is a
+// credential but a promo_code is not
+var sensitiveNames = []string{"code"}
+
+// isSensitiveParam reports whether the value of the query parameter name must not be logged
+func isSensitiveParam(name string) bool {
+	lower := strings.ToLower(name)
+	if slices.Contains(sensitiveNames, lower) {
+		return true
+	}
+	for _, sensitive := range sensitiveParams {
+		if lower == sensitive {
+			return true
+		}
+		i := len(lower) - len(sensitive)
+		if i > 0 && strings.HasSuffix(lower, sensitive) && wordStart(name, lower, i) {
+			return true
+		}
+	}
+	return false
+}
+
+// wordStart reports whether a word of name starts at byte i of its lower case form lower,
+// either after a '_', '-' or '.' or as a capital letter following a lower case one
+func wordStart(name, lower string, i int) bool {
+	switch lower[i-1] {
+	case '_', '-', '.':
+		return true
+	}
+	// Lowering non-ASCII names can shift byte offsets
+	if len(name) != len(lower) {
+		return false
+	}
+	return unicode.IsUpper(rune(name[i])) && unicode.IsLower(rune(name[i-1]))
+}
+
+// RedactQuery returns the query string with the values of sensitive parameters redacted
+func RedactQuery(query string) string {
+	if query == "" {
+		return ""
+	}
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		// Do not risk logging what could not be told apart
+		return Redacted
+	}
+	redacted := false
+	for name, vals := range values {
+		if isSensitiveParam(name) {
+			for i := range vals {
+				vals[i] = Redacted
+			}
+			redacted = true
+		}
+	}
+	if !redacted {
+		return query
+	}
+	return values.Encode()
+}
+
+// RedactURL returns raw with its password and the values of sensitive query parameters
+// redacted
+func RedactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		before, _, _ := strings.Cut(raw, "?")
+		return before
+	}
+	if _, ok := u.User.Password(); ok {
+		u.User = url.UserPassword(u.User.Username(), Redacted)
+	}
+	u.RawQuery = RedactQuery(u.RawQuery)
+	return u.String()
+}